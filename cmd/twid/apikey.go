@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/twipi/twipi/twid/audit"
+	auditsqlite "github.com/twipi/twipi/twid/audit/sqlite"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/sessions"
+	sessionsqlite "github.com/twipi/twipi/twid/sessions/sqlite"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+const apikeyUsage = `usage:
+  twid apikey create [--scope SCOPE]... [--phone-number NUMBER] NAME
+  twid apikey list
+  twid apikey revoke NAME`
+
+// runAPIKey runs the apikey subcommand, which manages API keys directly in
+// twid's storage.
+func runAPIKey(ctx context.Context, cfg *config.Root, args []string, logger *slog.Logger) error {
+	if cfg.Storage.InMemory {
+		return errors.New("API keys cannot be managed in in-memory storage")
+	}
+
+	if len(args) == 0 {
+		return errors.New(apikeyUsage)
+	}
+
+	storage := storage.New(cfg.Storage, logger)
+
+	store, err := sessionsqlite.NewSessionStore(ctx, storage, logger)
+	if err != nil {
+		return fmt.Errorf("failed to open session store: %w", err)
+	}
+	defer store.Close()
+
+	auditLog, err := auditsqlite.NewAuditLog(ctx, storage, logger)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer auditLog.Close()
+
+	switch args[0] {
+	case "create":
+		return createAPIKey(ctx, store, auditLog, args[1:], logger)
+	case "list":
+		return listAPIKeys(ctx, store)
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apikeyUsage)
+		}
+		if err := store.DeleteAPIKey(ctx, args[1]); err != nil {
+			if errors.Is(err, sessions.ErrNotFound) {
+				return fmt.Errorf("API key %q does not exist", args[1])
+			}
+			return err
+		}
+		return auditLog.Record(ctx, audit.Event{
+			Action:  audit.ActionAPIKeyRevoked,
+			Actor:   cliAuditActor,
+			Admin:   true,
+			Details: map[string]string{"name": args[1]},
+		})
+	default:
+		return errors.New(apikeyUsage)
+	}
+}
+
+// cliAuditActor is the actor of audit events recorded by the command line.
+const cliAuditActor = "cli"
+
+// scopeNames returns the names of all scopes, separated by commas.
+func scopeNames() string {
+	names := make([]string, len(sessions.Scopes))
+	for i, scope := range sessions.Scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ", ")
+}
+
+func createAPIKey(ctx context.Context, store sessions.Store, auditLog audit.Log, args []string, logger *slog.Logger) error {
+	var scopes []string
+	var phoneNumber string
+
+	flags := pflag.NewFlagSet("apikey create", pflag.ContinueOnError)
+	flags.StringSliceVar(&scopes, "scope", nil, "scopes to grant the key: "+scopeNames())
+	flags.StringVar(&phoneNumber, "phone-number", "", "phone number to bind the key to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New(apikeyUsage)
+	}
+
+	token, err := sessions.NewAPIKeyToken()
+	if err != nil {
+		return err
+	}
+
+	key := sessions.APIKey{
+		ID:          sessions.TokenID(token),
+		Name:        flags.Arg(0),
+		PhoneNumber: phoneNumber,
+		CreatedAt:   time.Now(),
+	}
+	for _, s := range scopes {
+		key.Scopes = append(key.Scopes, sessions.Scope(s))
+	}
+
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	if err := store.CreateAPIKey(ctx, key); err != nil {
+		if errors.Is(err, sessions.ErrExists) {
+			return fmt.Errorf("API key %q already exists", key.Name)
+		}
+		return err
+	}
+
+	// The token is never stored, so this is the only time it can be seen.
+	// Print it before anything else can fail.
+	fmt.Println(token)
+
+	if err := auditLog.Record(ctx, audit.Event{
+		Action:  audit.ActionAPIKeyCreated,
+		Actor:   cliAuditActor,
+		Subject: key.PhoneNumber,
+		Admin:   true,
+		Details: map[string]string{
+			"name":   key.Name,
+			"scopes": strings.Join(scopes, ","),
+		},
+	}); err != nil {
+		logger.Error(
+			"failed to record audit event",
+			"action", audit.ActionAPIKeyCreated,
+			"err", err)
+	}
+
+	return nil
+}
+
+func listAPIKeys(ctx context.Context, store sessions.Store) error {
+	keys, err := store.APIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCOPES\tPHONE NUMBER\tCREATED\tLAST USED")
+	for _, key := range keys {
+		scopes := make([]string, len(key.Scopes))
+		for i, scope := range key.Scopes {
+			scopes[i] = string(scope)
+		}
+
+		lastUsed := "never"
+		if !key.LastUsedAt.IsZero() {
+			lastUsed = key.LastUsedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			key.Name,
+			strings.Join(scopes, ","),
+			key.PhoneNumber,
+			key.CreatedAt.Format(time.RFC3339),
+			lastUsed)
+	}
+	return w.Flush()
+}
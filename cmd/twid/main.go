@@ -12,6 +12,8 @@ import (
 	"github.com/twipi/twipi/twid"
 	"github.com/twipi/twipi/twid/config"
 
+	_ "github.com/twipi/twipi/twicmd/exec"
+	_ "github.com/twipi/twipi/twicmd/grpc"
 	_ "github.com/twipi/twipi/twicmd/http"
 	_ "github.com/twipi/twipi/twicmd/slashparser"
 	_ "github.com/twipi/twipi/twisms/wsbridge"
@@ -25,20 +27,31 @@ var (
 func main() {
 	pflag.StringVarP(&configFile, "config", "c", configFile, "configuration file")
 	pflag.CountVarP(&verbosity, "verbose", "v", "verbosity level: warn (0), info, debug")
+	pflag.CommandLine.SetInterspersed(false)
 	pflag.Parse()
 
 	logger := setupLogging()
 	slog.SetDefault(logger)
 
-	cfg, err := cfgutil.ParseFile[config.Root](configFile)
+	cfg, err := config.ParseFile(configFile)
 	if err != nil {
 		logger.Error("failed to parse config file", "err", err)
 		os.Exit(1)
 	}
 
+	logger.Debug("loaded config file", "path", configFile, "config", cfg)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if pflag.Arg(0) == "apikey" {
+		if err := runAPIKey(ctx, cfg, pflag.Args()[1:], logger); err != nil {
+			logger.Error("apikey command failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := twid.Start(ctx, *cfg, logger); err != nil {
 		logger.Error("failed to start twid", "err", err)
 		os.Exit(1)
@@ -8,3 +8,6 @@ LIMIT 100;
 
 -- name: InsertMessage :exec
 INSERT INTO messages (from_number, to_number, created_at, protobuf_data) VALUES (?, ?, ?, ?);
+
+-- name: DeleteMessagesBefore :execrows
+DELETE FROM messages WHERE created_at < ?;
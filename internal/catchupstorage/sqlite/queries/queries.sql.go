@@ -10,6 +10,18 @@ import (
 	"strings"
 )
 
+const deleteMessagesBefore = `-- name: DeleteMessagesBefore :execrows
+DELETE FROM messages WHERE created_at < ?
+`
+
+func (q *Queries) DeleteMessagesBefore(ctx context.Context, createdAt int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMessagesBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const insertMessage = `-- name: InsertMessage :exec
 INSERT INTO messages (from_number, to_number, created_at, protobuf_data) VALUES (?, ?, ?, ?)
 `
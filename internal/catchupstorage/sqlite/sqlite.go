@@ -20,8 +20,11 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// Schema is the schema of the message storage database in the format
+// expected by lazymigrate.
+//
 //go:embed schema.sql
-var schema string
+var Schema string
 
 const pragma = `
 	PRAGMA journal_mode=WAL2;
@@ -62,7 +65,7 @@ func NewMessageStorage(ctx context.Context, cfg *StorageConfig, logger *slog.Log
 		return nil, fmt.Errorf("could not set SQLite PRAGMA: %w", err)
 	}
 
-	if err := lazymigrate.Migrate(ctx, db, schema); err != nil {
+	if err := lazymigrate.Migrate(ctx, db, Schema); err != nil {
 		return nil, fmt.Errorf("could not migrate SQLite database: %w", err)
 	}
 
@@ -73,6 +76,17 @@ func NewMessageStorage(ctx context.Context, cfg *StorageConfig, logger *slog.Log
 	}, nil
 }
 
+// NewMessageStorageFromDB creates a new SQLite storage backend for the message
+// queue using an existing database. The database must already be migrated to
+// [Schema]. Closing the storage closes the database.
+func NewMessageStorageFromDB(db *sql.DB, logger *slog.Logger) *MessageStorage {
+	return &MessageStorage{
+		db:     db,
+		q:      queries.New(db),
+		logger: logger,
+	}
+}
+
 func (s *MessageStorage) Close() error {
 	return s.db.Close()
 }
@@ -146,3 +160,12 @@ func (s *MessageStorage) StoreMessage(ctx context.Context, msg *twismsproto.Mess
 
 	return nil
 }
+
+// DeleteMessagesBefore deletes all messages created before the given time.
+func (s *MessageStorage) DeleteMessagesBefore(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.q.DeleteMessagesBefore(ctx, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("could not delete messages: %w", err)
+	}
+	return n, nil
+}
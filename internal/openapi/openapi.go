@@ -0,0 +1,432 @@
+// Package openapi generates OpenAPI 3.1 documents from chi route tables.
+//
+// Routes are documented by registering them with an [Endpoint], which records
+// the Protobuf messages that the route accepts and returns. Middlewares that
+// restrict access document themselves by wrapping the next handler with
+// [Require].
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"libdb.so/hrt"
+)
+
+// Endpoint is an HTTP handler annotated with the information needed to
+// document it.
+type Endpoint struct {
+	http.Handler
+	// Summary is a short summary of what the endpoint does.
+	Summary string
+	// Request is the message that the endpoint accepts. It is nil if the
+	// endpoint accepts nothing.
+	Request protoreflect.MessageDescriptor
+	// Response is the message that the endpoint returns. It is nil if the
+	// endpoint returns nothing.
+	Response protoreflect.MessageDescriptor
+	// Stream is true if the endpoint responds with a stream of server-sent
+	// events, each carrying a Response as its data.
+	Stream bool
+}
+
+// Wrap wraps f using [hrt.Wrap] and documents it using its request and
+// response types.
+func Wrap[RequestT, ResponseT any](f func(context.Context, RequestT) (ResponseT, error), summary string) *Endpoint {
+	return &Endpoint{
+		Handler:  hrt.Wrap(f),
+		Summary:  summary,
+		Request:  messageDescriptor[RequestT](),
+		Response: messageDescriptor[ResponseT](),
+	}
+}
+
+// messageDescriptor returns the message descriptor of T if T is a Protobuf
+// message, or nil otherwise.
+func messageDescriptor[T any]() protoreflect.MessageDescriptor {
+	var zero T
+	if msg, ok := any(zero).(proto.Message); ok {
+		return msg.ProtoReflect().Descriptor()
+	}
+	return nil
+}
+
+// Requirement describes what a middleware requires of the callers that it lets
+// through.
+type Requirement struct {
+	// Schemes are the names of the security schemes that the middleware
+	// authenticates with. Routes accept any of the schemes of all the
+	// middlewares that they are behind.
+	Schemes []string
+	// Roles is the list of roles or scopes that the middleware requires.
+	Roles []string
+	// Description describes the requirement to humans.
+	Description string
+}
+
+type requiredHandler struct {
+	http.Handler
+	requirement Requirement
+}
+
+// Require wraps next so that all routes behind it are documented with the
+// given requirement. It is meant to be called by middlewares on the handler
+// that they return.
+func Require(next http.Handler, requirement Requirement) http.Handler {
+	return requiredHandler{next, requirement}
+}
+
+// requirements returns the requirements of the given middlewares. Each
+// middleware is applied to a dummy handler to find out whether it wraps its
+// handler using [Require].
+func requirements(middlewares []func(http.Handler) http.Handler) []Requirement {
+	dummy := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var reqs []Requirement
+	for _, mw := range middlewares {
+		if h, ok := mw(dummy).(requiredHandler); ok {
+			reqs = append(reqs, h.requirement)
+		}
+	}
+	return reqs
+}
+
+// Config describes the parts of the document that can't be derived from the
+// route table.
+type Config struct {
+	Title       string
+	Description string
+	Version     string
+	// QueryParameter is the name of the query parameter that GET requests are
+	// encoded in as Protobuf JSON. If empty, GET requests carry a body like
+	// any other request.
+	QueryParameter string
+	// SecuritySchemes are the security schemes referenced by the
+	// requirements of the routes.
+	SecuritySchemes map[string]*SecurityScheme
+}
+
+// Serve returns a handler that serves the OpenAPI document of the given routes
+// as JSON. The document is generated on the first request, so the handler can
+// be registered on the same router that it documents.
+func Serve(routes chi.Routes, cfg Config) http.Handler {
+	generate := sync.OnceValues(func() ([]byte, error) {
+		return json.MarshalIndent(Generate(routes, cfg), "", "  ")
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := generate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+}
+
+// Generate generates the OpenAPI document of the given routes.
+func Generate(routes chi.Routes, cfg Config) *Document {
+	schemas := newSchemaBuilder()
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:       cfg.Title,
+			Description: cfg.Description,
+			Version:     cfg.Version,
+		},
+		// Resolve paths relative to wherever the document is served from,
+		// since the routes may be mounted anywhere.
+		Servers: []Server{{URL: "."}},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Responses: map[string]*Response{
+				"Error": errorResponse,
+			},
+			SecuritySchemes: cfg.SecuritySchemes,
+		},
+	}
+
+	walk(routes, "", nil, func(method, route string, handler http.Handler, middlewares []func(http.Handler) http.Handler) {
+		path, params := convertPattern(route)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+
+		item[strings.ToLower(method)] = newOperation(cfg, schemas, method, params, handler, middlewares)
+	})
+
+	doc.Components.Schemas = schemas.schemas
+	return doc
+}
+
+// walk is like [chi.Walk], except it also finds the middlewares of routers
+// mounted within groups, which chi.Walk misses.
+func walk(routes chi.Routes, prefix string, parentMiddlewares []func(http.Handler) http.Handler, walkFn func(method, route string, handler http.Handler, middlewares []func(http.Handler) http.Handler)) {
+	middlewares := append(slices.Clip(parentMiddlewares), routes.Middlewares()...)
+
+	for _, route := range routes.Routes() {
+		pattern := prefix + route.Pattern
+
+		if route.SubRoutes != nil {
+			// Routers mounted within a group are wrapped in a chain of the
+			// group's middlewares. Each method maps to the same chain.
+			subMiddlewares := middlewares
+			for _, handler := range route.Handlers {
+				if chain, ok := handler.(*chi.ChainHandler); ok {
+					subMiddlewares = append(slices.Clip(middlewares), chain.Middlewares...)
+				}
+				break
+			}
+
+			walk(route.SubRoutes, strings.TrimSuffix(pattern, "/*"), subMiddlewares, walkFn)
+			continue
+		}
+
+		for method, handler := range route.Handlers {
+			if method == "*" {
+				continue
+			}
+
+			handlerMiddlewares := middlewares
+			if chain, ok := handler.(*chi.ChainHandler); ok {
+				handler = chain.Endpoint
+				handlerMiddlewares = append(slices.Clip(middlewares), chain.Middlewares...)
+			}
+
+			walkFn(method, strings.ReplaceAll(pattern, "/*/", "/"), handler, handlerMiddlewares)
+		}
+	}
+}
+
+var errorResponse = &Response{
+	Description: "The request failed. The body contains the status code and the error message.",
+	Content: map[string]*MediaType{
+		"text/plain": {
+			Schema: &Schema{
+				Type:    "string",
+				Example: "404: not found",
+			},
+		},
+	},
+}
+
+var errorResponseRef = &Response{Ref: "#/components/responses/Error"}
+
+func newOperation(cfg Config, schemas *schemaBuilder, method string, params []string, handler http.Handler, middlewares []func(http.Handler) http.Handler) *Operation {
+	op := &Operation{
+		Responses: map[string]*Response{
+			"default": errorResponseRef,
+		},
+	}
+
+	for _, param := range params {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	endpoint, _ := handler.(*Endpoint)
+	if endpoint == nil {
+		endpoint = &Endpoint{}
+	}
+	op.Summary = endpoint.Summary
+
+	if endpoint.Request != nil {
+		content := map[string]*MediaType{
+			"application/json": {Schema: schemas.ref(endpoint.Request)},
+		}
+		if method == http.MethodGet && cfg.QueryParameter != "" {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     cfg.QueryParameter,
+				In:       "query",
+				Required: true,
+				Content:  content,
+			})
+		} else {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  content,
+			}
+		}
+	}
+
+	switch {
+	case endpoint.Stream:
+		op.Responses["200"] = &Response{
+			Description: "A stream of server-sent events. The data of each event is described by the schema.",
+			Content: map[string]*MediaType{
+				"text/event-stream": {Schema: schemas.ref(endpoint.Response)},
+			},
+		}
+	case endpoint.Response != nil:
+		op.Responses["200"] = &Response{
+			Description: "Success.",
+			Content: map[string]*MediaType{
+				"application/json": {Schema: schemas.ref(endpoint.Response)},
+			},
+		}
+	default:
+		op.Responses["200"] = &Response{Description: "Success. The body is empty."}
+	}
+
+	var roles []string
+	var schemes []string
+	var descriptions []string
+	for _, req := range requirements(middlewares) {
+		schemes = append(schemes, req.Schemes...)
+		if req.Description != "" {
+			descriptions = append(descriptions, req.Description)
+		}
+		roles = append(roles, req.Roles...)
+	}
+
+	if len(schemes) > 0 {
+		if roles == nil {
+			// Encode as an empty array rather than null.
+			roles = []string{}
+		}
+		for _, scheme := range schemes {
+			op.Security = append(op.Security, SecurityRequirement{scheme: roles})
+		}
+		op.Responses["401"] = errorResponseRef
+		op.Responses["403"] = errorResponseRef
+	}
+
+	op.Description = strings.Join(descriptions, " ")
+	return op
+}
+
+var patternParam = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// convertPattern converts a chi route pattern into an OpenAPI path and returns
+// the names of its parameters.
+func convertPattern(pattern string) (string, []string) {
+	var params []string
+	path := patternParam.ReplaceAllStringFunc(pattern, func(s string) string {
+		name := patternParam.FindStringSubmatch(s)[1]
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+
+	// chi routes "/foo" to the "/" route of a router mounted at "/foo", so
+	// there is no need for the trailing slash.
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path, params
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the metadata of an OpenAPI document.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a server that serves the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path.
+type PathItem map[string]*Operation
+
+// Operation is a single API operation on a path.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name     string                `json:"name"`
+	In       string                `json:"in"`
+	Required bool                  `json:"required,omitempty"`
+	Schema   *Schema               `json:"schema,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+// RequestBody is the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation. It is either a reference or an
+// inline response.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the body of a request or response of a specific content
+// type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the reusable objects of an OpenAPI document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes a way of authenticating.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to the roles required by an
+// operation.
+type SecurityRequirement map[string][]string
+
+// Schema is a JSON schema.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Example              any                `json:"example,omitempty"`
+}
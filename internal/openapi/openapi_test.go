@@ -0,0 +1,65 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"libdb.so/hrt"
+)
+
+func TestGenerate(t *testing.T) {
+	auth := func(next http.Handler) http.Handler {
+		return Require(next, Requirement{Schemes: []string{"bearer"}, Description: "Requires a token."})
+	}
+	admin := func(next http.Handler) http.Handler {
+		return Require(next, Requirement{Roles: []string{"admin"}})
+	}
+
+	r := chi.NewRouter()
+	r.Method(http.MethodPost, "/login", Wrap(
+		func(context.Context, *twidpb.LoginPhase1Request) (hrt.None, error) { return hrt.Empty, nil },
+		"Log in"))
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Route("/sessions", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", Wrap(
+				func(context.Context, *twidpb.ListSessionsRequest) (*twidpb.ListSessionsResponse, error) {
+					return nil, nil
+				},
+				"List sessions"))
+			r.With(admin).Method(http.MethodDelete, "/{id:[a-z]+}", Wrap(
+				func(context.Context, hrt.None) (hrt.None, error) { return hrt.Empty, nil },
+				"Revoke a session"))
+		})
+	})
+
+	doc := Generate(r, Config{Title: "test", QueryParameter: "params"})
+
+	login := doc.Paths["/login"]["post"]
+	assert.Equal(t, "Log in", login.Summary)
+	assert.Equal(t, "#/components/schemas/twid.LoginPhase1Request", login.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Zero(t, login.Security)
+
+	list := doc.Paths["/sessions"]["get"]
+	assert.Zero(t, list.RequestBody)
+	assert.Equal(t, "params", list.Parameters[0].Name)
+	assert.Equal(t, "query", list.Parameters[0].In)
+	assert.Equal(t, []SecurityRequirement{{"bearer": {}}}, list.Security)
+	assert.Equal(t, "Requires a token.", list.Description)
+
+	revoke := doc.Paths["/sessions/{id}"]["delete"]
+	assert.Equal(t, "id", revoke.Parameters[0].Name)
+	assert.Equal(t, "path", revoke.Parameters[0].In)
+	assert.Equal(t, []SecurityRequirement{{"bearer": {"admin"}}}, revoke.Security)
+	assert.Equal(t, "Success. The body is empty.", revoke.Responses["200"].Description)
+
+	// Referenced messages are collected, and well-known types are inlined.
+	session := doc.Components.Schemas["twid.Session"]
+	assert.NotZero(t, session)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, session.Properties["createdAt"])
+	assert.Equal(t, "#/components/schemas/twid.Session", doc.Components.Schemas["twid.ListSessionsResponse"].Properties["sessions"].Items.Ref)
+}
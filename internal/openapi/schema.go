@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaBuilder builds the component schemas of Protobuf messages as they are
+// encoded by protojson.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: make(map[string]*Schema)}
+}
+
+// ref returns a reference to the schema of the given message, building the
+// schema if needed.
+func (b *schemaBuilder) ref(md protoreflect.MessageDescriptor) *Schema {
+	if schema, ok := wellKnownSchemas[md.FullName()]; ok {
+		s := *schema
+		return &s
+	}
+
+	name := string(md.FullName())
+	if _, ok := b.schemas[name]; !ok {
+		// Reserve the name first, since messages may be recursive.
+		b.schemas[name] = nil
+		b.schemas[name] = b.message(md)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) message(md protoreflect.MessageDescriptor) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		schema.Properties[fd.JSONName()] = b.field(fd)
+	}
+
+	var oneofs []string
+	for i := 0; i < md.Oneofs().Len(); i++ {
+		od := md.Oneofs().Get(i)
+		if od.IsSynthetic() {
+			continue
+		}
+
+		names := make([]string, od.Fields().Len())
+		for j := range names {
+			names[j] = od.Fields().Get(j).JSONName()
+		}
+
+		oneofs = append(oneofs, fmt.Sprintf(
+			"At most one of %s may be set.",
+			strings.Join(names, ", ")))
+	}
+	schema.Description = strings.Join(oneofs, " ")
+
+	return schema
+}
+
+func (b *schemaBuilder) field(fd protoreflect.FieldDescriptor) *Schema {
+	switch {
+	case fd.IsMap():
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: b.singular(fd.MapValue()),
+		}
+	case fd.IsList():
+		return &Schema{
+			Type:  "array",
+			Items: b.singular(fd),
+		}
+	default:
+		return b.singular(fd)
+	}
+}
+
+func (b *schemaBuilder) singular(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson encodes 64-bit integers as strings.
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return &Schema{Type: "string", Enum: names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.ref(fd.Message())
+	default:
+		return &Schema{}
+	}
+}
+
+// wellKnownSchemas are the schemas of the well-known types, which protojson
+// encodes specially.
+var wellKnownSchemas = map[protoreflect.FullName]*Schema{
+	"google.protobuf.Timestamp": {Type: "string", Format: "date-time"},
+	"google.protobuf.Duration":  {Type: "string", Description: `Duration in seconds with an "s" suffix, e.g. "1.5s".`},
+	"google.protobuf.FieldMask": {Type: "string"},
+	"google.protobuf.Empty":     {Type: "object"},
+	"google.protobuf.Struct":    {Type: "object"},
+	"google.protobuf.Value":     {},
+	"google.protobuf.ListValue": {Type: "array", Items: &Schema{}},
+	"google.protobuf.Any":       {Type: "object", Properties: map[string]*Schema{"@type": {Type: "string"}}},
+
+	"google.protobuf.BoolValue":   {Type: "boolean"},
+	"google.protobuf.StringValue": {Type: "string"},
+	"google.protobuf.BytesValue":  {Type: "string", Format: "byte"},
+	"google.protobuf.Int32Value":  {Type: "integer", Format: "int32"},
+	"google.protobuf.UInt32Value": {Type: "integer", Format: "uint32"},
+	"google.protobuf.Int64Value":  {Type: "string", Format: "int64"},
+	"google.protobuf.UInt64Value": {Type: "string", Format: "uint64"},
+	"google.protobuf.FloatValue":  {Type: "number", Format: "float"},
+	"google.protobuf.DoubleValue": {Type: "number", Format: "double"},
+}
@@ -0,0 +1,53 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestCode(t *testing.T) {
+	// The SHA1 test vectors of RFC 6238, truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.code, Code(secret, Step(time.Unix(test.time, 0))))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Validate(secret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// Codes of adjacent steps are accepted.
+	step, ok = Validate(secret, "081804", now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, "081804", now.Add(2*Period))
+	assert.False(t, ok)
+	_, ok = Validate(secret, "81804", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Twipi", "+15555550123", []byte("12345678901234567890"))
+	assert.Equal(t,
+		"otpauth://totp/Twipi:+15555550123?algorithm=SHA1&digits=6&issuer=Twipi&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		uri)
+}
@@ -0,0 +1,273 @@
+package twicmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+)
+
+// aliasCommand is the name of the built-in command that manages the aliases of
+// the sender, e.g. "/alias add g /discord send DiscordGophers general". It is
+// handled before the message is parsed, so it works with any parser.
+const aliasCommand = "alias"
+
+const (
+	maxAliasNameLength      = 32
+	maxAliasExpansionLength = 480
+)
+
+// Alias is a shortcut that a user defines for a command. Sending "/name"
+// followed by arguments expands to the expansion before the message is
+// parsed, so aliases work with every parser.
+type Alias struct {
+	// Name is the name of the alias without the leading slash. It is always
+	// lowercase.
+	Name string
+	// Expansion is the text that the alias expands to. $1 to $9 are replaced
+	// with the words after the alias and $* with the rest of the message
+	// after the words used by $1 to $9. If the expansion has no $*, the rest
+	// of the message is appended to it. $$ is a literal $.
+	Expansion string
+}
+
+// ErrAliasNotFound is returned when an alias does not exist.
+var ErrAliasNotFound = errors.New("alias not found")
+
+// ErrInvalidAlias is returned when an alias cannot be saved.
+var ErrInvalidAlias = errors.New("invalid alias")
+
+// InvalidAliasError is returned when an alias cannot be saved. It matches
+// [ErrInvalidAlias].
+type InvalidAliasError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidAliasError) Error() string {
+	return fmt.Sprintf("invalid alias %q: %s", e.Name, e.Reason)
+}
+
+func (e *InvalidAliasError) Is(target error) bool {
+	return target == ErrInvalidAlias
+}
+
+// AliasStore stores the aliases of each user, keyed by their phone number.
+type AliasStore interface {
+	// Aliases returns the aliases of the phone number sorted by name.
+	Aliases(ctx context.Context, phoneNumber string) ([]Alias, error)
+	// Alias returns the alias of the phone number with the given name. It
+	// returns [ErrAliasNotFound] if there is none.
+	Alias(ctx context.Context, phoneNumber, name string) (Alias, error)
+	// SetAlias adds the alias or replaces the alias with the same name.
+	SetAlias(ctx context.Context, phoneNumber string, alias Alias) error
+	// DeleteAlias deletes the alias of the phone number with the given name.
+	// It returns [ErrAliasNotFound] if there is none.
+	DeleteAlias(ctx context.Context, phoneNumber, name string) error
+}
+
+// SetAlias validates the alias and saves it for the phone number. The name
+// may start with a slash and is lowercased. An [InvalidAliasError] is returned
+// if the alias is invalid, including if it would shadow a service. The saved
+// alias is returned.
+func (s *Manager) SetAlias(ctx context.Context, phoneNumber string, alias Alias) (Alias, error) {
+	if s.Aliases == nil {
+		return Alias{}, errors.New("aliases are not enabled")
+	}
+	return setAlias(ctx, s.Aliases, s.Services, phoneNumber, alias)
+}
+
+func setAlias(ctx context.Context, store AliasStore, lookup *ServiceLookup, phoneNumber string, alias Alias) (Alias, error) {
+	alias.Name = normalizeAliasName(alias.Name)
+	alias.Expansion = strings.TrimSpace(alias.Expansion)
+
+	invalid := func(reason string) (Alias, error) {
+		return Alias{}, &InvalidAliasError{Name: alias.Name, Reason: reason}
+	}
+
+	switch {
+	case alias.Name == "":
+		return invalid("missing name")
+	case len(alias.Name) > maxAliasNameLength:
+		return invalid(fmt.Sprintf("name is longer than %d characters", maxAliasNameLength))
+	case strings.ContainsFunc(alias.Name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}):
+		return invalid("name may only contain letters, digits, - and _")
+	case slices.Contains([]string{HelpService, aliasCommand, defaultCommand, exitCommand}, alias.Name):
+		return invalid("name is a built-in command")
+	case alias.Expansion == "":
+		return invalid("missing expansion")
+	case len(alias.Expansion) > maxAliasExpansionLength:
+		return invalid(fmt.Sprintf("expansion is longer than %d characters", maxAliasExpansionLength))
+	}
+
+	if _, ok := lookup.Service(alias.Name); ok {
+		return invalid("name is a service")
+	}
+
+	if err := store.SetAlias(ctx, phoneNumber, alias); err != nil {
+		return Alias{}, err
+	}
+	return alias, nil
+}
+
+func normalizeAliasName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+}
+
+// expandAlias expands the text if it starts with one of the aliases of the
+// sender. Services take precedence over aliases of the same name.
+func (d *dispatchContext) expandAlias(ctx context.Context, text string) (string, bool, error) {
+	word, args := cutWord(text)
+	if !strings.HasPrefix(word, "/") {
+		return text, false, nil
+	}
+
+	name := normalizeAliasName(word)
+	if _, ok := d.lookup.Service(name); ok {
+		return text, false, nil
+	}
+
+	alias, err := d.aliases.Alias(ctx, d.msg.From, name)
+	if err != nil {
+		if errors.Is(err, ErrAliasNotFound) {
+			return text, false, nil
+		}
+		return "", false, err
+	}
+
+	return expandAlias(alias.Expansion, args), true, nil
+}
+
+// expandAlias replaces the placeholders in the expansion with the arguments.
+// See [Alias] for the placeholders.
+func expandAlias(expansion, args string) string {
+	words := strings.Fields(args)
+
+	// $* is the rest after the highest numbered placeholder, wherever it is.
+	var used int
+	var hasRest bool
+	for i := 0; i < len(expansion)-1; i++ {
+		if expansion[i] != '$' {
+			continue
+		}
+		switch next := expansion[i+1]; {
+		case next >= '1' && next <= '9':
+			used = max(used, int(next-'0'))
+		case next == '*':
+			hasRest = true
+		}
+		i++
+	}
+
+	rest := args
+	for range used {
+		_, rest = cutWord(rest)
+	}
+	rest = strings.TrimSpace(rest)
+
+	var b strings.Builder
+	for i := 0; i < len(expansion); i++ {
+		if expansion[i] != '$' || i == len(expansion)-1 {
+			b.WriteByte(expansion[i])
+			continue
+		}
+		switch next := expansion[i+1]; {
+		case next >= '1' && next <= '9':
+			if n := int(next - '0'); n <= len(words) {
+				b.WriteString(words[n-1])
+			}
+		case next == '*':
+			b.WriteString(rest)
+		case next == '$':
+			b.WriteByte('$')
+		default:
+			b.WriteByte('$')
+			continue
+		}
+		i++
+	}
+
+	if !hasRest && rest != "" {
+		b.WriteByte(' ')
+		b.WriteString(rest)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// cutWord returns the first word of the text and the rest of it after the
+// whitespace that follows the word.
+func cutWord(text string) (word, rest string) {
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i == -1 {
+		return text, ""
+	}
+	return text[:i], strings.TrimLeftFunc(text[i:], unicode.IsSpace)
+}
+
+const aliasUsage = "" +
+	"Usage:\n" +
+	"/alias add <name> <command>\n" +
+	"/alias rm <name>\n" +
+	"/alias ls"
+
+// manageAliases executes an alias command, which manages the aliases of the
+// sender.
+func (d *dispatchContext) manageAliases(ctx context.Context, args string) (*twicmdproto.ExecuteResponse, error) {
+	subcommand, args := cutWord(args)
+
+	switch strings.ToLower(subcommand) {
+	case "ls", "list":
+		aliases, err := d.aliases.Aliases(ctx, d.msg.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(aliases) == 0 {
+			return statusResponse("You have no aliases. Send /alias add <name> <command> to add one."), nil
+		}
+
+		lines := []string{"Aliases:"}
+		for _, alias := range aliases {
+			lines = append(lines, "/"+alias.Name+" - "+alias.Expansion)
+		}
+		return TextResponse(strings.Join(lines, "\n")), nil
+
+	case "add", "set":
+		name, expansion := cutWord(args)
+		alias, err := setAlias(ctx, d.aliases, d.lookup, d.msg.From, Alias{
+			Name:      name,
+			Expansion: expansion,
+		})
+		if err != nil {
+			var invalid *InvalidAliasError
+			if errors.As(err, &invalid) {
+				return statusResponse(fmt.Sprintf("Cannot add alias: %s.", invalid.Reason)), nil
+			}
+			return nil, err
+		}
+		return statusResponse(fmt.Sprintf("/%s now expands to %q.", alias.Name, alias.Expansion)), nil
+
+	case "rm", "remove", "delete":
+		name := normalizeAliasName(args)
+		if name == "" {
+			return statusResponse(aliasUsage), nil
+		}
+		if err := d.aliases.DeleteAlias(ctx, d.msg.From, name); err != nil {
+			if errors.Is(err, ErrAliasNotFound) {
+				return statusResponse(fmt.Sprintf("You have no alias /%s.", name)), nil
+			}
+			return nil, err
+		}
+		return statusResponse(fmt.Sprintf("Removed /%s.", name)), nil
+
+	default:
+		return statusResponse(aliasUsage), nil
+	}
+}
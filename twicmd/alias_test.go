@@ -0,0 +1,149 @@
+package twicmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+)
+
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		expansion string
+		args      string
+		expanded  string
+	}{
+		{"/discord send DiscordGophers general", "", "/discord send DiscordGophers general"},
+		{"/discord send DiscordGophers general", "hi  there", "/discord send DiscordGophers general hi  there"},
+		{"/discord send DiscordGophers $1", "offtopic hi there", "/discord send DiscordGophers offtopic hi there"},
+		{"/discord send $1 $2 $*", "DiscordGophers offtopic hi  there", "/discord send DiscordGophers offtopic hi  there"},
+		{"/weather $* today", "new york", "/weather new york today"},
+		{"/send $2 $1", "a b c", "/send b a c"},
+		{"/send $1 $3", "a", "/send a"},
+		{"/pay $$5 $x", "", "/pay $5 $x"},
+		{"/pay $", "now", "/pay $ now"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expanded, expandAlias(test.expansion, test.args), "%q with %q", test.expansion, test.args)
+	}
+}
+
+// memoryAliasStore is an in-memory [AliasStore].
+type memoryAliasStore map[string]map[string]string
+
+func (s memoryAliasStore) Aliases(ctx context.Context, phoneNumber string) ([]Alias, error) {
+	var aliases []Alias
+	for name, expansion := range s[phoneNumber] {
+		aliases = append(aliases, Alias{Name: name, Expansion: expansion})
+	}
+	slices.SortFunc(aliases, func(a, b Alias) int { return strings.Compare(a.Name, b.Name) })
+	return aliases, nil
+}
+
+func (s memoryAliasStore) Alias(ctx context.Context, phoneNumber, name string) (Alias, error) {
+	expansion, ok := s[phoneNumber][name]
+	if !ok {
+		return Alias{}, ErrAliasNotFound
+	}
+	return Alias{Name: name, Expansion: expansion}, nil
+}
+
+func (s memoryAliasStore) SetAlias(ctx context.Context, phoneNumber string, alias Alias) error {
+	if s[phoneNumber] == nil {
+		s[phoneNumber] = map[string]string{}
+	}
+	s[phoneNumber][alias.Name] = alias.Expansion
+	return nil
+}
+
+func (s memoryAliasStore) DeleteAlias(ctx context.Context, phoneNumber, name string) error {
+	if _, ok := s[phoneNumber][name]; !ok {
+		return ErrAliasNotFound
+	}
+	delete(s[phoneNumber], name)
+	return nil
+}
+
+// wordsParser parses "/chat send <channel> <message...>".
+type wordsParser struct{}
+
+func (wordsParser) Name() string { return "words" }
+
+func (wordsParser) Parse(ctx context.Context, _ *ServiceLookup, body *twismsproto.MessageBody) (*twicmdproto.Command, error) {
+	words := strings.SplitN(body.GetText().GetText(), " ", 4)
+	if len(words) < 4 || words[0] != "/chat" || words[1] != "send" {
+		return nil, nil
+	}
+	return &twicmdproto.Command{
+		Service: "chat",
+		Command: "send",
+		Arguments: []*twicmdproto.CommandArgument{
+			{Name: "channel", Value: words[2]},
+			{Name: "message", Value: words[3]},
+		},
+	}, nil
+}
+
+func TestAliases(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	manager := &Manager{
+		Parsers:  []CommandParser{wordsParser{}},
+		Services: lookup,
+		Aliases:  memoryAliasStore{},
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	send := func(from, text string) *twicmdproto.ExecuteResponse {
+		resp, err := manager.Execute(ctx, &twismsproto.Message{
+			From: from,
+			To:   "+15555550100",
+			Body: &twismsproto.MessageBody{Text: &twismsproto.TextBody{Text: text}},
+		})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	const number = "+15555550123"
+
+	assert.Equal(t,
+		StatusResponse("You have no aliases. Send /alias add <name> <command> to add one."),
+		send(number, "/alias ls"))
+	assert.Equal(t,
+		StatusResponse(`/g now expands to "/chat send general".`),
+		send(number, "/alias add /G /chat send general"))
+	assert.Equal(t,
+		StatusResponse(`/to now expands to "/chat send $1".`),
+		send(number, "/Alias add to /chat send $1"))
+	assert.Equal(t,
+		TextResponse("Aliases:\n/g - /chat send general\n/to - /chat send $1"),
+		send(number, "/alias ls"))
+
+	assert.Equal(t, TextResponse("general: hello there"), send(number, "/g hello there"))
+	assert.Equal(t, TextResponse("random: hi"), send(number, "/to random hi"))
+	assert.Equal(t,
+		StatusResponse(defaultFallbackText),
+		send("+15555550100", "/g hello there"),
+		"aliases are per user")
+
+	assert.Equal(t, StatusResponse("Cannot add alias: name is a service."), send(number, "/alias add chat /chat send general"))
+	assert.Equal(t, StatusResponse("Cannot add alias: name is a built-in command."), send(number, "/alias add help /help"))
+	assert.Equal(t, StatusResponse("Cannot add alias: missing expansion."), send(number, "/alias add x"))
+	assert.Equal(t, StatusResponse("Cannot add alias: name may only contain letters, digits, - and _."), send(number, "/alias add x! /help"))
+
+	assert.Equal(t, StatusResponse("Removed /g."), send(number, "/alias rm g"))
+	assert.Equal(t, StatusResponse("You have no alias /g."), send(number, "/alias rm /g"))
+	assert.Equal(t, StatusResponse(aliasUsage), send(number, "/alias"))
+
+	_, err := manager.SetAlias(ctx, number, Alias{Name: "chat", Expansion: "/chat send general"})
+	assert.IsError(t, err, ErrInvalidAlias)
+}
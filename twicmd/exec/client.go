@@ -0,0 +1,451 @@
+// Package execservice provides a [twicmd.Service] that runs as a child process
+// of twid and talks to it over its standard input and output.
+//
+// # Protocol
+//
+// twid writes HostPacket messages to the standard input of the process and
+// reads ServicePacket messages from its standard output. Each packet is a
+// Protobuf message prefixed with its length as a big-endian uint32.
+//
+// Every HostPacket is a request that the process must reply to with a
+// ServicePacket of the same ID, carrying either the matching response or an
+// error. The process may also send ServicePackets with ID 0 at any time to send
+// out messages.
+//
+// Configuration and autocompletion are optional. twid asks the process for its
+// Capabilities and only sends the matching requests if it supports them.
+//
+// Anything the process writes to its standard error is logged by twid. If the
+// process exits, it is restarted after a delay.
+//
+// Services written in Go may use [Serve] to implement the protocol.
+package execservice
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/twipi/cfgutil"
+	"github.com/twipi/pubsub"
+	"github.com/twipi/twipi/internal/xcontainer"
+	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
+	"github.com/twipi/twipi/proto/out/twicmdexecproto"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid"
+	"github.com/twipi/twipi/twisms"
+	"golang.org/x/sync/errgroup"
+)
+
+// ClientConfig is the expected configuration for an exec service.
+type ClientConfig struct {
+	// Name is the name of the service.
+	Name string `json:"name"`
+	// Command is the command to run, including its arguments.
+	Command []string `json:"command"`
+	// Dir is the working directory of the process.
+	// If empty, twid's working directory is used.
+	Dir string `json:"dir,omitempty"`
+	// Env is the additional environment variables to give the process.
+	// The process also inherits twid's environment.
+	Env map[string]string `json:"env,omitempty"`
+	// RestartDelay is the delay before restarting the process after it exits.
+	// It defaults to 2 seconds.
+	RestartDelay cfgutil.Duration `json:"restart_delay,omitempty"`
+}
+
+func init() {
+	twid.RegisterTwicmdService(twid.TwicmdService{
+		Name: "exec",
+		New: func(cfg json.RawMessage, mctx twid.ModuleContext) (twicmd.Service, error) {
+			var config ClientConfig
+			if err := json.Unmarshal(cfg, &config); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal exec service config: %w", err)
+			}
+			client, err := NewClient(config, mctx.Logger)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+	})
+}
+
+// errNotRunning is returned when a request is made while the process is not
+// running.
+var errNotRunning = errors.New("service process is not running")
+
+// Client runs a service process and implements [twicmd.Service] over it.
+type Client struct {
+	cfg    ClientConfig
+	logger *slog.Logger
+	subs   pubsub.Subscriber[*twismsproto.Message]
+	msgs   chan *twismsproto.Message
+	proc   atomic.Pointer[process]
+
+	cachedService      xcontainer.Expirable[*twicmdproto.Service]
+	cachedCapabilities xcontainer.Expirable[twicmd.Capabilities]
+}
+
+var (
+	_ twicmd.Service               = (*Client)(nil)
+	_ twicmd.CapableService        = (*Client)(nil)
+	_ twicmd.ReloadableService     = (*Client)(nil)
+	_ twicmd.ConfigurableService   = (*Client)(nil)
+	_ twicmd.AutocompletingService = (*Client)(nil)
+	_ twid.Starter                 = (*Client)(nil)
+)
+
+// NewClient creates a new exec service client. The process is not started
+// until [Client.Start] is called.
+func NewClient(cfg ClientConfig, logger *slog.Logger) (*Client, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("exec service has no name")
+	}
+	if len(cfg.Command) == 0 {
+		return nil, errors.New("exec service has no command")
+	}
+	if cfg.RestartDelay == 0 {
+		cfg.RestartDelay = cfgutil.Duration(2 * time.Second)
+	}
+
+	return &Client{
+		cfg: cfg,
+		logger: logger.With(
+			"service", cfg.Name,
+			"command", cfg.Command[0]),
+		msgs: make(chan *twismsproto.Message),
+	}, nil
+}
+
+// Start implements [twid.Starter]. It starts the process and restarts it
+// whenever it exits until the context is canceled.
+func (s *Client) Start(ctx context.Context) error {
+	errg, ctx := errgroup.WithContext(ctx)
+
+	errg.Go(func() error {
+		return s.subs.Listen(ctx, s.msgs)
+	})
+
+	errg.Go(func() error {
+		for {
+			err := s.runProcess(ctx)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			s.logger.Error(
+				"service process exited, restarting...",
+				"delay", s.cfg.RestartDelay.AsDuration(),
+				"err", err)
+
+			timer := time.NewTimer(s.cfg.RestartDelay.AsDuration())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	})
+
+	return errg.Wait()
+}
+
+func (s *Client) runProcess(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, s.cfg.Command[0], s.cfg.Command[1:]...)
+	cmd.Dir = s.cfg.Dir
+	cmd.Env = os.Environ()
+	for k, v := range s.cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("could not create stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("could not create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("could not create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start process: %w", err)
+	}
+
+	logger := s.logger.With("pid", cmd.Process.Pid)
+	logger.Info("started service process")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logStderr(stderr, logger)
+	}()
+
+	readErr := s.serve(ctx, stdout, stdin, logger)
+
+	// The process may still be alive if it only closed its stdout, so make
+	// sure that it's dead before waiting on it.
+	cmd.Process.Kill()
+	wg.Wait()
+
+	waitErr := cmd.Wait()
+	return errors.Join(readErr, waitErr)
+}
+
+// serve sends requests to the service over w and reads its packets from r
+// until r is closed.
+func (s *Client) serve(ctx context.Context, r io.Reader, w io.Writer, logger *slog.Logger) error {
+	proc := &process{
+		w:       newPacketWriter(w),
+		pending: xsync.NewMapOf[uint64, chan *twicmdexecproto.ServicePacket](),
+		done:    make(chan struct{}),
+	}
+	s.proc.Store(proc)
+
+	// The service may have changed since the last process.
+	s.Reload()
+
+	err := s.readPackets(ctx, r, proc, logger)
+
+	s.proc.CompareAndSwap(proc, nil)
+	close(proc.done)
+
+	return err
+}
+
+func (s *Client) readPackets(ctx context.Context, r io.Reader, proc *process, logger *slog.Logger) error {
+	reader := newPacketReader(r)
+	for {
+		pkt := new(twicmdexecproto.ServicePacket)
+		if err := reader.read(pkt); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("could not read packet: %w", err)
+		}
+
+		if pkt.Id != 0 {
+			ch, ok := proc.pending.Load(pkt.Id)
+			if !ok {
+				logger.Warn(
+					"received reply for unknown request",
+					"id", pkt.Id)
+				continue
+			}
+			select {
+			case ch <- pkt:
+			default:
+				logger.Warn(
+					"received duplicate reply for request",
+					"id", pkt.Id)
+			}
+			continue
+		}
+
+		msg := pkt.GetMessage()
+		if msg == nil {
+			logger.Warn(
+				"received unexpected packet without ID",
+				"packet", pkt.String())
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s.msgs <- msg:
+		}
+	}
+}
+
+func logStderr(r io.Reader, logger *slog.Logger) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Info(
+			"service process stderr",
+			"line", scanner.Text())
+	}
+}
+
+// process is a running service process.
+type process struct {
+	w       *packetWriter
+	id      atomic.Uint64
+	pending *xsync.MapOf[uint64, chan *twicmdexecproto.ServicePacket]
+	done    chan struct{}
+}
+
+func (p *process) request(ctx context.Context, pkt *twicmdexecproto.HostPacket) (*twicmdexecproto.ServicePacket, error) {
+	pkt.Id = p.id.Add(1)
+
+	ch := make(chan *twicmdexecproto.ServicePacket, 1)
+	p.pending.Store(pkt.Id, ch)
+	defer p.pending.Delete(pkt.Id)
+
+	if err := p.w.write(pkt); err != nil {
+		return nil, fmt.Errorf("could not send request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, errNotRunning
+	case resp := <-ch:
+		if err := resp.GetError(); err != nil {
+			return nil, errors.New(err.Message)
+		}
+		return resp, nil
+	}
+}
+
+func (s *Client) request(ctx context.Context, pkt *twicmdexecproto.HostPacket) (*twicmdexecproto.ServicePacket, error) {
+	proc := s.proc.Load()
+	if proc == nil {
+		return nil, errNotRunning
+	}
+	return proc.request(ctx, pkt)
+}
+
+// SubscribeMessages implements [twisms.MessageSubscriber].
+func (s *Client) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
+	s.subs.Subscribe(ch, func(msg *twismsproto.Message) bool {
+		return twisms.FilterMessage(filters, msg)
+	})
+}
+
+// UnsubscribeMessages implements [twisms.MessageSubscriber].
+func (s *Client) UnsubscribeMessages(ch chan<- *twismsproto.Message) {
+	s.subs.Unsubscribe(ch)
+}
+
+// Name implements [twicmd.Service].
+func (s *Client) Name() string {
+	return s.cfg.Name
+}
+
+// Service implements [twicmd.Service].
+func (s *Client) Service(ctx context.Context) (*twicmdproto.Service, error) {
+	return s.cachedService.RenewableValue(5*time.Minute, func() (*twicmdproto.Service, error) {
+		resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+			Body: &twicmdexecproto.HostPacket_Service{
+				Service: &twicmdexecproto.ServiceRequest{},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return expectBody(resp, resp.GetService())
+	})
+}
+
+// Execute implements [twicmd.Service].
+func (s *Client) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+		Body: &twicmdexecproto.HostPacket_Execute{
+			Execute: req,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expectBody(resp, resp.GetExecute())
+}
+
+// Reload implements [twicmd.ReloadableService].
+func (s *Client) Reload() {
+	s.cachedService.Renew(0, nil)
+	s.cachedCapabilities.Renew(0, twicmd.Capabilities{})
+}
+
+// Capabilities implements [twicmd.CapableService].
+func (s *Client) Capabilities(ctx context.Context) (twicmd.Capabilities, error) {
+	return s.cachedCapabilities.RenewableValue(5*time.Minute, func() (twicmd.Capabilities, error) {
+		resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+			Body: &twicmdexecproto.HostPacket_Capabilities{
+				Capabilities: &twicmdexecproto.CapabilitiesRequest{},
+			},
+		})
+		if err != nil {
+			return twicmd.Capabilities{}, err
+		}
+		caps, err := expectBody(resp, resp.GetCapabilities())
+		if err != nil {
+			return twicmd.Capabilities{}, err
+		}
+		return twicmd.Capabilities{
+			Configurable:   caps.Configurable,
+			Autocompleting: caps.Autocompleting,
+		}, nil
+	})
+}
+
+// ConfigurationValues implements [twicmd.ConfigurableService].
+func (s *Client) ConfigurationValues(ctx context.Context, req *twicmdcfgpb.OptionsRequest) (*twicmdcfgpb.OptionsResponse, error) {
+	resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+		Body: &twicmdexecproto.HostPacket_ConfigurationValues{
+			ConfigurationValues: req,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expectBody(resp, resp.GetConfigurationValues())
+}
+
+// ApplyConfigurationValues implements [twicmd.ConfigurableService].
+func (s *Client) ApplyConfigurationValues(ctx context.Context, req *twicmdcfgpb.ApplyRequest) (*twicmdcfgpb.ApplyResponse, error) {
+	resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+		Body: &twicmdexecproto.HostPacket_ApplyConfigurationValues{
+			ApplyConfigurationValues: req,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expectBody(resp, resp.GetApplyConfigurationValues())
+}
+
+// Autocomplete implements [twicmd.AutocompletingService].
+func (s *Client) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	resp, err := s.request(ctx, &twicmdexecproto.HostPacket{
+		Body: &twicmdexecproto.HostPacket_Autocomplete{
+			Autocomplete: req,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expectBody(resp, resp.GetAutocomplete())
+}
+
+// expectBody returns v if it is set, or an error if the process replied with
+// a different body than expected.
+func expectBody[T comparable](pkt *twicmdexecproto.ServicePacket, v T) (T, error) {
+	var z T
+	if v == z {
+		return z, fmt.Errorf("service process replied with unexpected %T", pkt.Body)
+	}
+	return v, nil
+}
@@ -0,0 +1,78 @@
+package execservice
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// maxPacketSize is the maximum size of a single packet. It guards against
+// reading garbage from a misbehaving process.
+const maxPacketSize = 16 << 20 // 16 MiB
+
+// packetReader reads length-prefixed Protobuf packets.
+type packetReader struct {
+	r *bufio.Reader
+}
+
+func newPacketReader(r io.Reader) *packetReader {
+	return &packetReader{r: bufio.NewReader(r)}
+}
+
+func (r *packetReader) read(msg proto.Message) error {
+	var size uint32
+	if err := binary.Read(r.r, binary.BigEndian, &size); err != nil {
+		return err
+	}
+
+	if size > maxPacketSize {
+		return fmt.Errorf("packet too large (%d bytes)", size)
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return fmt.Errorf("could not read packet: %w", err)
+	}
+
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return fmt.Errorf("could not unmarshal packet: %w", err)
+	}
+
+	return nil
+}
+
+// packetWriter writes length-prefixed Protobuf packets.
+// It is safe for concurrent use.
+type packetWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newPacketWriter(w io.Writer) *packetWriter {
+	return &packetWriter{w: w}
+}
+
+func (w *packetWriter) write(msg proto.Message) error {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal packet: %w", err)
+	}
+
+	if len(b) > maxPacketSize {
+		return fmt.Errorf("packet too large (%d bytes)", len(b))
+	}
+
+	buf := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(buf, uint32(len(b)))
+	copy(buf[4:], b)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.w.Write(buf)
+	return err
+}
@@ -0,0 +1,68 @@
+package execservice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdexecproto"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPackets(t *testing.T) {
+	packets := []*twicmdexecproto.HostPacket{
+		{Id: 1, Body: &twicmdexecproto.HostPacket_Service{Service: &twicmdexecproto.ServiceRequest{}}},
+		// An empty packet has a length of 0.
+		{},
+		{Id: 2, Body: &twicmdexecproto.HostPacket_Execute{Execute: &twicmdproto.ExecuteRequest{
+			Command: &twicmdproto.Command{Service: "echo", Command: "say"},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	w := newPacketWriter(&buf)
+	for _, pkt := range packets {
+		assert.NoError(t, w.write(pkt))
+	}
+
+	// Each packet is its Protobuf encoding prefixed with its big-endian length.
+	b, err := proto.Marshal(packets[0])
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(len(b)), binary.BigEndian.Uint32(buf.Bytes()))
+	assert.Equal(t, b, buf.Bytes()[4:4+len(b)])
+
+	r := newPacketReader(&buf)
+	for _, want := range packets {
+		got := new(twicmdexecproto.HostPacket)
+		assert.NoError(t, r.read(got))
+		assert.True(t, proto.Equal(want, got), got.String())
+	}
+
+	err = r.read(new(twicmdexecproto.HostPacket))
+	assert.True(t, errors.Is(err, io.EOF), "expected EOF, got %v", err)
+}
+
+func TestPackets_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"short length", []byte{0, 0}},
+		{"truncated body", []byte{0, 0, 0, 4, 0x08}},
+		{"too large", binary.BigEndian.AppendUint32(nil, maxPacketSize+1)},
+		{"invalid body", []byte{0, 0, 0, 1, 0xFF}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newPacketReader(bytes.NewReader(test.input))
+			err := r.read(new(twicmdexecproto.HostPacket))
+			assert.Error(t, err)
+			assert.False(t, errors.Is(err, io.EOF), "unexpected EOF")
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package execservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/twipi/twipi/proto/out/twicmdexecproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+)
+
+// Serve serves the given service to twid over the given reader and writer,
+// which are usually the standard input and output of the process. It blocks
+// until the reader is closed or a packet cannot be read.
+//
+// Nothing else may be written to w while Serve is running. Logs should go to
+// the standard error instead.
+func Serve(ctx context.Context, service twicmd.Service, r io.Reader, w io.Writer) error {
+	// Stop the goroutines before waiting for them.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := newPacketWriter(w)
+
+	msgs := make(chan *twismsproto.Message)
+	service.SubscribeMessages(msgs, nil)
+	defer service.UnsubscribeMessages(msgs)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-msgs:
+				writer.write(&twicmdexecproto.ServicePacket{
+					Body: &twicmdexecproto.ServicePacket_Message{
+						Message: msg,
+					},
+				})
+			}
+		}
+	}()
+
+	reader := newPacketReader(r)
+	for {
+		pkt := new(twicmdexecproto.HostPacket)
+		if err := reader.read(pkt); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp := handlePacket(ctx, service, pkt)
+			resp.Id = pkt.Id
+			writer.write(resp)
+		}()
+	}
+}
+
+func handlePacket(ctx context.Context, service twicmd.Service, pkt *twicmdexecproto.HostPacket) *twicmdexecproto.ServicePacket {
+	resp, err := handleRequest(ctx, service, pkt)
+	if err != nil {
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_Error{
+				Error: &twicmdexecproto.Error{Message: err.Error()},
+			},
+		}
+	}
+	return resp
+}
+
+func handleRequest(ctx context.Context, service twicmd.Service, pkt *twicmdexecproto.HostPacket) (*twicmdexecproto.ServicePacket, error) {
+	switch body := pkt.Body.(type) {
+	case *twicmdexecproto.HostPacket_Service:
+		desc, err := service.Service(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_Service{Service: desc},
+		}, nil
+
+	case *twicmdexecproto.HostPacket_Execute:
+		resp, err := service.Execute(ctx, body.Execute)
+		if err != nil {
+			return nil, err
+		}
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_Execute{Execute: resp},
+		}, nil
+
+	case *twicmdexecproto.HostPacket_Capabilities:
+		_, configurable := service.(twicmd.ConfigurableService)
+		_, autocompleting := service.(twicmd.AutocompletingService)
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_Capabilities{
+				Capabilities: &twicmdexecproto.Capabilities{
+					Configurable:   configurable,
+					Autocompleting: autocompleting,
+				},
+			},
+		}, nil
+
+	case *twicmdexecproto.HostPacket_ConfigurationValues:
+		configurable, ok := service.(twicmd.ConfigurableService)
+		if !ok {
+			return nil, errors.New("service is not configurable")
+		}
+		resp, err := configurable.ConfigurationValues(ctx, body.ConfigurationValues)
+		if err != nil {
+			return nil, err
+		}
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_ConfigurationValues{ConfigurationValues: resp},
+		}, nil
+
+	case *twicmdexecproto.HostPacket_ApplyConfigurationValues:
+		configurable, ok := service.(twicmd.ConfigurableService)
+		if !ok {
+			return nil, errors.New("service is not configurable")
+		}
+		resp, err := configurable.ApplyConfigurationValues(ctx, body.ApplyConfigurationValues)
+		if err != nil {
+			return nil, err
+		}
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_ApplyConfigurationValues{ApplyConfigurationValues: resp},
+		}, nil
+
+	case *twicmdexecproto.HostPacket_Autocomplete:
+		autocompleter, ok := service.(twicmd.AutocompletingService)
+		if !ok {
+			return nil, errors.New("service does not support autocompletion")
+		}
+		resp, err := autocompleter.Autocomplete(ctx, body.Autocomplete)
+		if err != nil {
+			return nil, err
+		}
+		return &twicmdexecproto.ServicePacket{
+			Body: &twicmdexecproto.ServicePacket_Autocomplete{Autocomplete: resp},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown request %T", body)
+	}
+}
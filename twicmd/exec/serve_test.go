@@ -0,0 +1,133 @@
+package execservice
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+)
+
+// echoService is an autocompleting but not configurable service that echoes
+// the text given to its say command.
+type echoService struct {
+	msgs chan chan<- *twismsproto.Message
+}
+
+func (echoService) Name() string { return "echo" }
+
+func (echoService) Service(context.Context) (*twicmdproto.Service, error) {
+	return &twicmdproto.Service{
+		Name: "echo",
+		Commands: []*twicmdproto.CommandDescription{
+			{Name: "say"},
+		},
+	}, nil
+}
+
+func (echoService) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	args := twicmd.MapArguments(req.Command.Arguments)
+	return twicmd.TextResponse(args["text"]), nil
+}
+
+func (echoService) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	return &twicmdproto.ArgumentAutocompleteResponse{
+		Suggestions: []string{req.Input + "lo"},
+	}, nil
+}
+
+func (s echoService) SubscribeMessages(ch chan<- *twismsproto.Message, _ *twismsproto.MessageFilters) {
+	s.msgs <- ch
+}
+
+func (echoService) UnsubscribeMessages(chan<- *twismsproto.Message) {}
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client, err := NewClient(ClientConfig{Name: "echo", Command: []string{"echo"}}, logger)
+	assert.NoError(t, err)
+
+	service := echoService{msgs: make(chan chan<- *twismsproto.Message, 1)}
+
+	hostR, hostW := io.Pipe()
+	serviceR, serviceW := io.Pipe()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, service, hostR, serviceW)
+		serviceW.Close()
+	}()
+
+	clientDone := make(chan error, 1)
+	go func() { clientDone <- client.serve(ctx, serviceR, hostW, logger) }()
+	go client.subs.Listen(ctx, client.msgs)
+
+	for client.proc.Load() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	desc, err := client.Service(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", desc.Name)
+
+	resp, err := client.Execute(ctx, &twicmdproto.ExecuteRequest{
+		Command: &twicmdproto.Command{
+			Service:   "echo",
+			Command:   "say",
+			Arguments: []*twicmdproto.CommandArgument{{Name: "text", Value: "hello"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", resp.GetText())
+
+	caps, err := client.Capabilities(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, twicmd.Capabilities{Autocompleting: true}, caps)
+
+	_, ok := twicmd.AsConfigurable(ctx, client)
+	assert.False(t, ok, "client is configurable")
+	autocompleter, ok := twicmd.AsAutocompleting(ctx, client)
+	assert.True(t, ok, "client is not autocompleting")
+
+	suggestions, err := autocompleter.Autocomplete(ctx, &twicmdproto.ArgumentAutocompleteRequest{
+		Service:  "echo",
+		Command:  "say",
+		Argument: "text",
+		Input:    "hel",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, suggestions.Suggestions)
+
+	_, err = client.ConfigurationValues(ctx, nil)
+	assert.EqualError(t, err, "service is not configurable")
+
+	// Messages sent by the service come out of the client.
+	received := make(chan *twismsproto.Message)
+	client.SubscribeMessages(received, nil)
+	t.Cleanup(func() { client.UnsubscribeMessages(received) })
+
+	(<-service.msgs) <- &twismsproto.Message{To: "+15555550123"}
+	select {
+	case msg := <-received:
+		assert.Equal(t, "+15555550123", msg.To)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for message")
+	}
+
+	// Closing the input stops the service, which then stops the client.
+	assert.NoError(t, hostW.Close())
+	assert.NoError(t, <-served)
+	assert.NoError(t, <-clientDone)
+
+	_, err = client.Execute(ctx, &twicmdproto.ExecuteRequest{})
+	assert.IsError(t, err, errNotRunning)
+}
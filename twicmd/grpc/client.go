@@ -0,0 +1,318 @@
+// Package grpcservice provides a gRPC transport for [twicmd.Service]. It
+// allows services to be written in any language that has gRPC support by
+// implementing the TwicmdService definition in twicmdgrpc.proto.
+//
+// Both TCP and Unix socket addresses are supported. Unix socket addresses are
+// written as "unix:///path/to/socket".
+package grpcservice
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/twipi/pubsub"
+	"github.com/twipi/twipi/internal/xcontainer"
+	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
+	"github.com/twipi/twipi/proto/out/twicmdgrpcproto"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid"
+	"github.com/twipi/twipi/twisms"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ClientConfig is the expected configuration for a gRPC service.
+type ClientConfig struct {
+	// Name is the name of the service.
+	Name string `json:"name"`
+	// Address is the address of the gRPC server, e.g. "localhost:9000" or
+	// "unix:///run/service.sock".
+	Address string `json:"address"`
+	// TLS is the TLS configuration for connecting to the server.
+	// If nil, the connection is not encrypted.
+	TLS *TLSConfig `json:"tls,omitempty"`
+}
+
+// TLSConfig is the TLS configuration of a gRPC connection.
+type TLSConfig struct {
+	// CAFile is the path to the CA certificate used to verify the peer.
+	// If empty, the system's root CAs are used.
+	CAFile string `json:"ca_file,omitempty"`
+	// CertFile is the path to the certificate to present to the peer.
+	// It is required for servers and is optional for clients.
+	CertFile string `json:"cert_file,omitempty"`
+	// KeyFile is the path to the private key of CertFile.
+	KeyFile string `json:"key_file,omitempty"`
+	// ServerName overrides the server name used to verify the server's
+	// certificate. It is only used by clients.
+	ServerName string `json:"server_name,omitempty"`
+}
+
+// Config returns the [tls.Config] described by the configuration for clients.
+func (c *TLSConfig) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.CAFile != "" {
+		b, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in CA file %q", c.CAFile)
+		}
+
+		cfg.RootCAs = pool
+		cfg.ClientCAs = pool
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// ServerConfig returns the [tls.Config] described by the configuration for
+// servers. If CAFile is set, clients must present a certificate signed by it.
+func (c *TLSConfig) ServerConfig() (*tls.Config, error) {
+	if c.CertFile == "" {
+		return nil, errors.New("servers require a certificate")
+	}
+
+	cfg, err := c.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ClientCAs != nil {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+func init() {
+	twid.RegisterTwicmdService(twid.TwicmdService{
+		Name: "grpc",
+		New: func(cfg json.RawMessage, mctx twid.ModuleContext) (twicmd.Service, error) {
+			var config ClientConfig
+			if err := json.Unmarshal(cfg, &config); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal gRPC service config: %w", err)
+			}
+			client, err := NewClient(config, mctx.Logger)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+	})
+}
+
+// Client wraps a gRPC connection and implements [twicmd.Service].
+type Client struct {
+	conn   *grpc.ClientConn
+	client twicmdgrpcproto.TwicmdServiceClient
+	logger *slog.Logger
+	subs   pubsub.Subscriber[*twismsproto.Message]
+	msgs   chan *twismsproto.Message
+	name   string
+
+	cachedService      xcontainer.Expirable[*twicmdproto.Service]
+	cachedCapabilities xcontainer.Expirable[twicmd.Capabilities]
+}
+
+var (
+	_ twicmd.Service               = (*Client)(nil)
+	_ twicmd.CapableService        = (*Client)(nil)
+	_ twicmd.ConfigurableService   = (*Client)(nil)
+	_ twicmd.AutocompletingService = (*Client)(nil)
+	_ twicmd.ReloadableService     = (*Client)(nil)
+	_ twid.Starter                 = (*Client)(nil)
+	_ io.Closer                    = (*Client)(nil)
+)
+
+// NewClient creates a new gRPC service client. The connection is established
+// lazily.
+func NewClient(cfg ClientConfig, logger *slog.Logger) (*Client, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("gRPC service has no name")
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: %w", err)
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("could not create gRPC client: %w", err)
+	}
+
+	return &Client{
+		conn:   conn,
+		client: twicmdgrpcproto.NewTwicmdServiceClient(conn),
+		logger: logger.With(
+			"service", cfg.Name,
+			"address", cfg.Address),
+		msgs: make(chan *twismsproto.Message),
+		name: cfg.Name,
+	}, nil
+}
+
+// Start implements [twid.Starter].
+func (s *Client) Start(ctx context.Context) error {
+	errg, ctx := errgroup.WithContext(ctx)
+
+	errg.Go(func() error {
+		return s.subs.Listen(ctx, s.msgs)
+	})
+
+	errg.Go(func() error {
+		const retryDelay = 2 * time.Second
+		const retryJitter = 1 * time.Second
+
+		for {
+			err := s.streamMessages(ctx)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			delay := retryDelay + time.Duration(rand.IntN(int(retryJitter)))
+			s.logger.Error(
+				"messages stream ended, retrying...",
+				"delay", delay,
+				"err", err)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	})
+
+	return errg.Wait()
+}
+
+// Close closes the gRPC connection.
+func (s *Client) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Client) streamMessages(ctx context.Context) error {
+	stream, err := s.client.Messages(ctx, &twicmdgrpcproto.MessagesRequest{})
+	if err != nil {
+		return fmt.Errorf("could not start messages stream: %w", err)
+	}
+
+	s.logger.Debug("connected to messages stream")
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// The stream is never expected to end.
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s.msgs <- msg:
+		}
+	}
+}
+
+// SubscribeMessages implements [twisms.MessageSubscriber].
+func (s *Client) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
+	s.subs.Subscribe(ch, func(msg *twismsproto.Message) bool {
+		return twisms.FilterMessage(filters, msg)
+	})
+}
+
+// UnsubscribeMessages implements [twisms.MessageSubscriber].
+func (s *Client) UnsubscribeMessages(ch chan<- *twismsproto.Message) {
+	s.subs.Unsubscribe(ch)
+}
+
+// Name implements [twicmd.Service].
+func (s *Client) Name() string {
+	return s.name
+}
+
+// Service implements [twicmd.Service].
+func (s *Client) Service(ctx context.Context) (*twicmdproto.Service, error) {
+	return s.cachedService.RenewableValue(5*time.Minute, func() (*twicmdproto.Service, error) {
+		return s.client.Service(ctx, &twicmdgrpcproto.ServiceRequest{})
+	})
+}
+
+// Reload implements [twicmd.ReloadableService].
+func (s *Client) Reload() {
+	s.cachedService.Renew(0, nil)
+	s.cachedCapabilities.Renew(0, twicmd.Capabilities{})
+}
+
+// Capabilities implements [twicmd.CapableService].
+func (s *Client) Capabilities(ctx context.Context) (twicmd.Capabilities, error) {
+	return s.cachedCapabilities.RenewableValue(5*time.Minute, func() (twicmd.Capabilities, error) {
+		caps, err := s.client.Capabilities(ctx, &twicmdgrpcproto.CapabilitiesRequest{})
+		if err != nil {
+			return twicmd.Capabilities{}, err
+		}
+		return twicmd.Capabilities{
+			Configurable:   caps.Configurable,
+			Autocompleting: caps.Autocompleting,
+		}, nil
+	})
+}
+
+// Execute implements [twicmd.Service].
+func (s *Client) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	return s.client.Execute(ctx, req)
+}
+
+// ConfigurationValues implements [twicmd.ConfigurableService].
+func (s *Client) ConfigurationValues(ctx context.Context, req *twicmdcfgpb.OptionsRequest) (*twicmdcfgpb.OptionsResponse, error) {
+	return s.client.ConfigurationValues(ctx, req)
+}
+
+// ApplyConfigurationValues implements [twicmd.ConfigurableService].
+func (s *Client) ApplyConfigurationValues(ctx context.Context, req *twicmdcfgpb.ApplyRequest) (*twicmdcfgpb.ApplyResponse, error) {
+	return s.client.ApplyConfigurationValues(ctx, req)
+}
+
+// Autocomplete implements [twicmd.AutocompletingService].
+func (s *Client) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	return s.client.Autocomplete(ctx, req)
+}
@@ -0,0 +1,292 @@
+package grpcservice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// echoService is an autocompleting but not configurable service that echoes
+// the text given to its say command.
+type echoService struct {
+	msgs chan chan<- *twismsproto.Message
+}
+
+func (echoService) Name() string { return "echo" }
+
+func (echoService) Service(context.Context) (*twicmdproto.Service, error) {
+	return &twicmdproto.Service{
+		Name: "echo",
+		Commands: []*twicmdproto.CommandDescription{
+			{Name: "say"},
+		},
+	}, nil
+}
+
+func (echoService) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	args := twicmd.MapArguments(req.Command.Arguments)
+	return twicmd.TextResponse(args["text"]), nil
+}
+
+func (echoService) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	return &twicmdproto.ArgumentAutocompleteResponse{
+		Suggestions: []string{req.Input + "lo"},
+	}, nil
+}
+
+func (s echoService) SubscribeMessages(ch chan<- *twismsproto.Message, _ *twismsproto.MessageFilters) {
+	s.msgs <- ch
+}
+
+func (echoService) UnsubscribeMessages(chan<- *twismsproto.Message) {}
+
+func TestRoundTrip(t *testing.T) {
+	service := echoService{msgs: make(chan chan<- *twismsproto.Message, 1)}
+	addr := "unix://" + filepath.Join(t.TempDir(), "echo.sock")
+	ctx := serve(t, addr, nil, service)
+
+	client := newClient(t, ClientConfig{Name: "echo", Address: addr})
+
+	desc, err := client.Service(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", desc.Name)
+
+	resp, err := client.Execute(ctx, &twicmdproto.ExecuteRequest{
+		Command: &twicmdproto.Command{
+			Service:   "echo",
+			Command:   "say",
+			Arguments: []*twicmdproto.CommandArgument{{Name: "text", Value: "hello"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", resp.GetText())
+
+	suggestions, err := client.Autocomplete(ctx, &twicmdproto.ArgumentAutocompleteRequest{
+		Service:  "echo",
+		Command:  "say",
+		Argument: "text",
+		Input:    "hel",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, suggestions.Suggestions)
+
+	_, err = client.ConfigurationValues(ctx, &twicmdcfgpb.OptionsRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// The client only claims the capabilities of the service.
+	caps, err := client.Capabilities(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, twicmd.Capabilities{Autocompleting: true}, caps)
+
+	_, ok := twicmd.AsConfigurable(ctx, client)
+	assert.False(t, ok)
+	_, ok = twicmd.AsAutocompleting(ctx, client)
+	assert.True(t, ok)
+
+	// Messages sent by the service come out of the client.
+	received := make(chan *twismsproto.Message)
+	client.SubscribeMessages(received, nil)
+	t.Cleanup(func() { client.UnsubscribeMessages(received) })
+
+	go client.Start(ctx)
+
+	msgs := <-service.msgs
+	select {
+	case msgs <- &twismsproto.Message{To: "+15555550123"}:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the messages stream")
+	}
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "+15555550123", msg.To)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestRoundTrip_TLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCertificateAuthority(t)
+	ca.issue(t, filepath.Join(dir, "server"))
+	ca.issue(t, filepath.Join(dir, "client"))
+
+	serverTLS := &TLSConfig{
+		CAFile:   ca.file,
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+	tlsConfig, err := serverTLS.ServerConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	service := echoService{msgs: make(chan chan<- *twismsproto.Message, 1)}
+	addr := "unix://" + filepath.Join(dir, "echo.sock")
+	ctx := serve(t, addr, tlsConfig, service)
+
+	tests := []struct {
+		name string
+		tls  *TLSConfig
+		ok   bool
+	}{
+		{
+			name: "client certificate",
+			tls: &TLSConfig{
+				CAFile:     ca.file,
+				CertFile:   filepath.Join(dir, "client.crt"),
+				KeyFile:    filepath.Join(dir, "client.key"),
+				ServerName: "localhost",
+			},
+			ok: true,
+		},
+		{
+			name: "no client certificate",
+			tls: &TLSConfig{
+				CAFile:     ca.file,
+				ServerName: "localhost",
+			},
+			ok: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newClient(t, ClientConfig{Name: "echo", Address: addr, TLS: test.tls})
+			_, err := client.Service(ctx)
+			if test.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+// serve serves the service on the address until the test ends. It returns
+// the context of the test.
+func serve(t *testing.T, addr string, tlsConfig *tls.Config, service twicmd.Service) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	served := make(chan error, 1)
+	go func() { served <- ListenAndServe(ctx, addr, tlsConfig, service, logger) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.IsError(t, <-served, context.Canceled)
+	})
+
+	// Wait for the socket to be created.
+	path := addr[len("unix://"):]
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return ctx
+		}
+		select {
+		case err := <-served:
+			t.Fatal("server stopped:", err)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func newClient(t *testing.T, cfg ClientConfig) *Client {
+	t.Helper()
+
+	client, err := NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newCertificateAuthority(t *testing.T) *certificateAuthority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "twipi test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "ca.crt")
+	writePEM(t, file, "CERTIFICATE", der)
+
+	return &certificateAuthority{cert: cert, key: key, file: file}
+}
+
+// issue writes a certificate for localhost signed by the CA to name.crt and
+// its key to name.key.
+func (ca *certificateAuthority) issue(t *testing.T, name string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: filepath.Base(name)},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	writePEM(t, name+".crt", "CERTIFICATE", der)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	writePEM(t, name+".key", "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	b := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
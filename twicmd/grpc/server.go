@@ -0,0 +1,171 @@
+package grpcservice
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
+	"github.com/twipi/twipi/proto/out/twicmdgrpcproto"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Server wraps a [twicmd.Service] and implements the gRPC TwicmdService.
+type Server struct {
+	twicmdgrpcproto.UnimplementedTwicmdServiceServer
+	service  twicmd.Service
+	logger   *slog.Logger
+	msgQueue chan *twismsproto.Message
+}
+
+var (
+	_ twicmdgrpcproto.TwicmdServiceServer = (*Server)(nil)
+	_ io.Closer                           = (*Server)(nil)
+)
+
+// NewServer creates a new gRPC server with the given service. Register it
+// using [twicmdgrpcproto.RegisterTwicmdServiceServer], or use
+// [ListenAndServe].
+func NewServer(service twicmd.Service, logger *slog.Logger) *Server {
+	msgQueue := make(chan *twismsproto.Message)
+	service.SubscribeMessages(msgQueue, nil)
+
+	return &Server{
+		service:  service,
+		logger:   logger,
+		msgQueue: msgQueue,
+	}
+}
+
+// Close frees resources used by the server.
+func (s *Server) Close() error {
+	s.service.UnsubscribeMessages(s.msgQueue)
+	return nil
+}
+
+// Service implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) Service(ctx context.Context, _ *twicmdgrpcproto.ServiceRequest) (*twicmdproto.Service, error) {
+	return s.service.Service(ctx)
+}
+
+// Execute implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	return s.service.Execute(ctx, req)
+}
+
+// Capabilities implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) Capabilities(ctx context.Context, _ *twicmdgrpcproto.CapabilitiesRequest) (*twicmdgrpcproto.CapabilitiesResponse, error) {
+	_, configurable := s.service.(twicmd.ConfigurableService)
+	_, autocompleting := s.service.(twicmd.AutocompletingService)
+	return &twicmdgrpcproto.CapabilitiesResponse{
+		Configurable:   configurable,
+		Autocompleting: autocompleting,
+	}, nil
+}
+
+// ConfigurationValues implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) ConfigurationValues(ctx context.Context, req *twicmdcfgpb.OptionsRequest) (*twicmdcfgpb.OptionsResponse, error) {
+	configurable, ok := s.service.(twicmd.ConfigurableService)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "service is not configurable")
+	}
+	return configurable.ConfigurationValues(ctx, req)
+}
+
+// ApplyConfigurationValues implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) ApplyConfigurationValues(ctx context.Context, req *twicmdcfgpb.ApplyRequest) (*twicmdcfgpb.ApplyResponse, error) {
+	configurable, ok := s.service.(twicmd.ConfigurableService)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "service is not configurable")
+	}
+	return configurable.ApplyConfigurationValues(ctx, req)
+}
+
+// Autocomplete implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	autocompleter, ok := s.service.(twicmd.AutocompletingService)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "service does not support autocompletion")
+	}
+	return autocompleter.Autocomplete(ctx, req)
+}
+
+// Messages implements [twicmdgrpcproto.TwicmdServiceServer].
+func (s *Server) Messages(_ *twicmdgrpcproto.MessagesRequest, stream twicmdgrpcproto.TwicmdService_MessagesServer) error {
+	ctx := stream.Context()
+
+	s.logger.Debug("gRPC message stream connected")
+	defer s.logger.Debug("gRPC message stream disconnected")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-s.msgQueue:
+			if err := stream.Send(msg); err != nil {
+				s.logger.Error(
+					"failed to send message, dropping it",
+					"message.from", msg.From,
+					"message.to", msg.To,
+					"err", err)
+				return err
+			}
+		}
+	}
+}
+
+// ListenAndServe serves the given service over gRPC on the given address until
+// the context is canceled. The address is either a TCP address or a Unix
+// socket address, e.g. "unix:///run/service.sock". If tlsConfig is not nil,
+// connections are served over TLS. It is usually created with
+// [TLSConfig.ServerConfig].
+func ListenAndServe(ctx context.Context, addr string, tlsConfig *tls.Config, service twicmd.Service, logger *slog.Logger) error {
+	lis, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := NewServer(service, logger)
+	defer server.Close()
+
+	grpcServer := grpc.NewServer(opts...)
+	twicmdgrpcproto.RegisterTwicmdServiceServer(grpcServer, server)
+
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	if err := grpcServer.Serve(lis); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		// Remove the stale socket left behind by a previous run.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not remove stale socket: %w", err)
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
@@ -0,0 +1,354 @@
+package twicmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+)
+
+// HelpService is the name of the built-in service that describes the other
+// services. Parsers should return [HelpCommand] for requests for help, e.g.
+// "/help discord send".
+const HelpService = "help"
+
+// Arguments of help commands. All of them are optional.
+const (
+	HelpArgService = "service"
+	HelpArgCommand = "command"
+	HelpArgPage    = "page"
+)
+
+// helpPageSize is the maximum length of a page of help, which is about three
+// concatenated SMS segments.
+const helpPageSize = 3 * 153
+
+// HelpCommand returns the command that asks for help about the given service
+// and command. Both may be empty to list the services or the commands of the
+// service. page is 1-based; 0 means the first page.
+func HelpCommand(service, command string, page int) *twicmdproto.Command {
+	cmd := &twicmdproto.Command{
+		Service: HelpService,
+		Command: HelpService,
+	}
+	add := func(name, value string) {
+		cmd.Arguments = append(cmd.Arguments, &twicmdproto.CommandArgument{
+			Name:  name,
+			Value: value,
+		})
+	}
+	if service != "" {
+		add(HelpArgService, service)
+	}
+	if command != "" {
+		add(HelpArgCommand, command)
+	}
+	if page > 0 {
+		add(HelpArgPage, strconv.Itoa(page))
+	}
+	return cmd
+}
+
+// help executes a help command using the services of the pipeline.
+func (d *dispatchContext) help(ctx context.Context, command *twicmdproto.Command) (*twicmdproto.ExecuteResponse, error) {
+	args := MapArguments(command.Arguments)
+	serviceName := args[HelpArgService]
+	commandName := args[HelpArgCommand]
+
+	page := 1
+	if args[HelpArgPage] != "" {
+		p, err := strconv.Atoi(args[HelpArgPage])
+		if err != nil || p < 1 {
+			return statusResponse(fmt.Sprintf("Invalid page %q.", args[HelpArgPage])), nil
+		}
+		page = p
+	}
+
+	var text string
+	var err error
+	switch {
+	case serviceName == "":
+		text, err = d.helpServices(ctx)
+	case commandName == "":
+		text, err = d.helpService(ctx, serviceName)
+	default:
+		text, err = d.helpCommand(ctx, serviceName, commandName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave room for the footer.
+	pages := paginate(text, helpPageSize-64)
+	if page > len(pages) {
+		return statusResponse(fmt.Sprintf("Invalid page %d, the last page is %d.", page, len(pages))), nil
+	}
+
+	text = pages[page-1]
+	if page < len(pages) {
+		next := HelpCommand(serviceName, commandName, page+1)
+		text += fmt.Sprintf("\n(%d/%d) Send %q for more.", page, len(pages), helpUsage(next))
+	} else if len(pages) > 1 {
+		text += fmt.Sprintf("\n(%d/%d)", page, len(pages))
+	}
+
+	return TextResponse(text), nil
+}
+
+func (d *dispatchContext) helpServices(ctx context.Context) (string, error) {
+	var services []*twicmdproto.Service
+	var err error
+	d.lookup.ResolveAllServices(ctx)(func(service *ResolvedService, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		services = append(services, service.Description)
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(services) == 0 {
+		return "No services are available.", nil
+	}
+
+	slices.SortFunc(services, func(a, b *twicmdproto.Service) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	lines := []string{"Services:"}
+	for _, service := range services {
+		lines = append(lines, describe("/"+service.Name, service.Description))
+	}
+	lines = append(lines, "Send /help <service> for its commands.")
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *dispatchContext) helpService(ctx context.Context, serviceName string) (string, error) {
+	service, err := d.lookup.Lookup(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
+	if service == nil {
+		return d.unknownService(serviceName), nil
+	}
+
+	desc := service.Description
+	title := desc.GetHumanName()
+	if title == "" {
+		title = "/" + desc.Name
+	}
+	lines := []string{describe(title, desc.Description)}
+
+	if len(desc.Commands) == 0 {
+		lines = append(lines, "This service has no commands.")
+	} else {
+		lines = append(lines, "Commands:")
+		for _, command := range desc.Commands {
+			lines = append(lines, describe(command.Name, command.Description))
+		}
+		lines = append(lines, fmt.Sprintf("Send /help %s <command> for its usage.", desc.Name))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *dispatchContext) helpCommand(ctx context.Context, serviceName, commandName string) (string, error) {
+	service, err := d.lookup.Lookup(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
+	if service == nil {
+		return d.unknownService(serviceName), nil
+	}
+
+	i := slices.IndexFunc(service.Description.Commands, func(c *twicmdproto.CommandDescription) bool {
+		return c.Name == commandName
+	})
+	if i == -1 {
+		names := make([]string, len(service.Description.Commands))
+		for i, command := range service.Description.Commands {
+			names[i] = command.Name
+		}
+		_, suggestions := SuggestOpts{}.match(commandName, names)
+
+		if suggestion := didYouMean(suggestions, slashCommand(service.Description.Name)); suggestion != "" {
+			return fmt.Sprintf("Unknown command %q, %s", commandName, suggestion), nil
+		}
+		return fmt.Sprintf("Unknown command %q. Send /help %s for a list of commands.", commandName, serviceName), nil
+	}
+	command := service.Description.Commands[i]
+
+	lines := []string{CommandUsage(service.Description, command)}
+	if command.Description != "" {
+		lines = append(lines, command.Description)
+	}
+	for _, name := range ArgumentNames(command) {
+		arg := command.Arguments[name]
+
+		var notes []string
+		if !arg.Required {
+			notes = append(notes, "optional")
+		}
+		if hint := hintName(arg.Hint); hint != "" {
+			notes = append(notes, hint)
+		}
+
+		label := name
+		if len(notes) > 0 {
+			label += " (" + strings.Join(notes, ", ") + ")"
+		}
+		lines = append(lines, describe(label, arg.Description))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *dispatchContext) unknownService(name string) string {
+	if suggestion := didYouMean(d.lookup.suggestServices(name), slashService); suggestion != "" {
+		return fmt.Sprintf("Unknown service %q, %s", name, suggestion)
+	}
+	return fmt.Sprintf("Unknown service %q. Send /help for a list of services.", name)
+}
+
+// CommandUsage returns the usage of the command as a slash command. Required
+// arguments are in angle brackets and optional ones in square brackets. The
+// trailing argument, which takes the rest of the message, ends with "...".
+func CommandUsage(service *twicmdproto.Service, command *twicmdproto.CommandDescription) string {
+	words := []string{"/" + service.Name, command.Name}
+
+	if len(command.ArgumentPositions) > 0 {
+		for i, name := range command.ArgumentPositions {
+			word := name
+			if command.ArgumentTrailing && i == len(command.ArgumentPositions)-1 {
+				word += "..."
+			}
+			if command.Arguments[name].GetRequired() {
+				word = "<" + word + ">"
+			} else {
+				word = "[" + word + "]"
+			}
+			words = append(words, word)
+		}
+	} else {
+		for _, name := range ArgumentNames(command) {
+			word := name + "=<value>"
+			if !command.Arguments[name].GetRequired() {
+				word = "[" + word + "]"
+			}
+			words = append(words, word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ArgumentNames returns the names of the arguments of the command, positional
+// arguments first in order and then the rest sorted.
+func ArgumentNames(command *twicmdproto.CommandDescription) []string {
+	named := make([]string, 0, len(command.Arguments))
+	for name := range command.Arguments {
+		if !slices.Contains(command.ArgumentPositions, name) {
+			named = append(named, name)
+		}
+	}
+	slices.Sort(named)
+	return append(slices.Clone(command.ArgumentPositions), named...)
+}
+
+// MissingArguments returns the names of the required arguments of the command
+// that are not given, in the order of [ArgumentNames].
+func MissingArguments(command *twicmdproto.CommandDescription, arguments []*twicmdproto.CommandArgument) []string {
+	given := MapArguments(arguments)
+
+	var missing []string
+	for _, name := range ArgumentNames(command) {
+		if command.Arguments[name].GetRequired() && given[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// helpUsage returns the slash command that asks for the given help.
+func helpUsage(command *twicmdproto.Command) string {
+	args := MapArguments(command.Arguments)
+	words := []string{"/" + HelpService}
+	for _, name := range []string{HelpArgService, HelpArgCommand, HelpArgPage} {
+		if args[name] != "" {
+			words = append(words, args[name])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func hintName(hint twicmdproto.CommandArgumentHint) string {
+	if hint == twicmdproto.CommandArgumentHint_COMMAND_ARGUMENT_HINT_UNSPECIFIED {
+		return ""
+	}
+	name := strings.TrimPrefix(hint.String(), "COMMAND_ARGUMENT_HINT_")
+	return strings.ReplaceAll(strings.ToLower(name), "_", " ")
+}
+
+// describe returns "name - description", or just the name if there is no
+// description.
+func describe(name, description string) string {
+	if description == "" {
+		return name
+	}
+	if name == "" {
+		return description
+	}
+	return name + " - " + description
+}
+
+// paginate splits the text into pages of at most size bytes, preferring to
+// split between lines.
+func paginate(text string, size int) []string {
+	var pages []string
+	var page strings.Builder
+
+	flush := func() {
+		if page.Len() > 0 {
+			pages = append(pages, page.String())
+			page.Reset()
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if page.Len() > 0 && page.Len()+1+len(line) > size {
+			flush()
+		}
+		// Lines that don't fit on a page of their own are split at word
+		// boundaries if possible.
+		for len(line) > size {
+			cut := strings.LastIndexByte(line[:size], ' ')
+			if cut <= 0 {
+				cut = size
+				// Don't split UTF-8 sequences.
+				for cut > 0 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+			}
+			pages = append(pages, strings.TrimSpace(line[:cut]))
+			line = strings.TrimSpace(line[cut:])
+		}
+		if page.Len() > 0 {
+			page.WriteByte('\n')
+		}
+		page.WriteString(line)
+	}
+	flush()
+
+	if len(pages) == 0 {
+		pages = append(pages, "")
+	}
+	return pages
+}
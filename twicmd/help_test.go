@@ -0,0 +1,119 @@
+package twicmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+)
+
+func TestHelp(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	manager := &Manager{
+		Services: lookup,
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	help := func(service, command string, page int) string {
+		resp, err := manager.ExecuteCommand(ctx, &twismsproto.Message{}, HelpCommand(service, command, page))
+		assert.NoError(t, err)
+		if status := resp.GetStatus(); status != "" {
+			return status
+		}
+		return resp.GetText()
+	}
+
+	assert.Equal(t, ""+
+		"Services:\n"+
+		"/chat\n"+
+		"Send /help <service> for its commands.",
+		help("", "", 0))
+
+	assert.Equal(t, ""+
+		"/chat\n"+
+		"Commands:\n"+
+		"send\n"+
+		"Send /help chat <command> for its usage.",
+		help("chat", "", 0))
+
+	assert.Equal(t, ""+
+		"/chat send channel=<value> message=<value>\n"+
+		"channel - The channel to send to.\n"+
+		"message",
+		help("chat", "send", 0))
+
+	assert.Equal(t, `Unknown command "nope". Send /help chat for a list of commands.`, help("chat", "nope", 0))
+	assert.Equal(t, `Unknown command "sned", did you mean /chat send?`, help("chat", "sned", 0))
+	assert.Equal(t, `Unknown service "chta", did you mean /chat?`, help("chta", "", 0))
+	assert.Equal(t, "Invalid page 2, the last page is 1.", help("", "", 2))
+}
+
+func TestCommandUsage(t *testing.T) {
+	service := &twicmdproto.Service{Name: "discord"}
+	command := &twicmdproto.CommandDescription{
+		Name: "send",
+		Arguments: map[string]*twicmdproto.CommandArgumentDescription{
+			"guild":   {},
+			"channel": {Required: true},
+			"message": {Required: true},
+		},
+		ArgumentPositions: []string{"guild", "channel", "message"},
+		ArgumentTrailing:  true,
+	}
+	assert.Equal(t, "/discord send [guild] <channel> <message...>", CommandUsage(service, command))
+}
+
+func TestMissingArguments(t *testing.T) {
+	command := &twicmdproto.CommandDescription{
+		Name: "send",
+		Arguments: map[string]*twicmdproto.CommandArgumentDescription{
+			"guild":   {},
+			"channel": {Required: true},
+			"message": {Required: true},
+			"silent":  {Required: true},
+		},
+		ArgumentPositions: []string{"guild", "channel", "message"},
+	}
+
+	assert.Equal(t, []string{"channel", "message", "silent"}, MissingArguments(command, nil))
+	assert.Equal(t, []string{"message"}, MissingArguments(command, []*twicmdproto.CommandArgument{
+		{Name: "channel", Value: "general"},
+		{Name: "silent", Value: "true"},
+	}))
+	assert.Equal(t, nil, MissingArguments(command, []*twicmdproto.CommandArgument{
+		{Name: "channel", Value: "general"},
+		{Name: "message", Value: "hi"},
+		{Name: "silent", Value: "true"},
+	}))
+}
+
+func TestPaginate(t *testing.T) {
+	lines := make([]string, 10)
+	for i := range lines {
+		lines[i] = strings.Repeat(string(rune('a'+i)), 9)
+	}
+
+	pages := paginate(strings.Join(lines, "\n"), 25)
+	assert.Equal(t, []string{
+		"aaaaaaaaa\nbbbbbbbbb",
+		"ccccccccc\nddddddddd",
+		"eeeeeeeee\nfffffffff",
+		"ggggggggg\nhhhhhhhhh",
+		"iiiiiiiii\njjjjjjjjj",
+	}, pages)
+
+	pages = paginate("hello there this is a long line", 12)
+	assert.Equal(t, []string{"hello there", "this is a", "long line"}, pages)
+
+	for _, page := range paginate(strings.Repeat("é", 20), 7) {
+		assert.True(t, len(page) <= 7 && strings.Trim(page, "é") == "", "page %q", page)
+	}
+}
@@ -7,6 +7,9 @@
 //   - GET / - returns the service description in Protobuf format.
 //   - POST /execute - accepts a command in Protobuf format and returns the result
 //     message body in Protobuf format.
+//   - POST /autocomplete - accepts an argument autocomplete request in Protobuf
+//     format and returns the suggestions in Protobuf format.
+//   - GET /openapi.json - returns the OpenAPI document of the API.
 package httpservice
 
 import (
@@ -47,12 +50,12 @@ type ClientConfig struct {
 func init() {
 	twid.RegisterTwicmdService(twid.TwicmdService{
 		Name: "http",
-		New: func(cfg json.RawMessage, logger *slog.Logger) (twicmd.Service, error) {
+		New: func(cfg json.RawMessage, mctx twid.ModuleContext) (twicmd.Service, error) {
 			var config ClientConfig
 			if err := json.Unmarshal(cfg, &config); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal HTTP service config: %w", err)
 			}
-			return NewClient(config.Name, config.BaseURL, logger), nil
+			return NewClient(config.Name, config.BaseURL, mctx.Logger), nil
 		},
 	})
 }
@@ -71,9 +74,11 @@ type Client struct {
 }
 
 var (
-	_ twicmd.Service           = (*Client)(nil)
-	_ twisms.MessageSubscriber = (*Client)(nil)
-	_ twid.Starter             = (*Client)(nil)
+	_ twicmd.Service               = (*Client)(nil)
+	_ twicmd.AutocompletingService = (*Client)(nil)
+	_ twicmd.ReloadableService     = (*Client)(nil)
+	_ twisms.MessageSubscriber     = (*Client)(nil)
+	_ twid.Starter                 = (*Client)(nil)
 )
 
 // NewClient creates a new HTTP service with the given URL.
@@ -255,6 +260,8 @@ func (s *Client) Name() string {
 var (
 	routeService = hrtclient.GET[hrt.None, *twicmdproto.Service]("/")
 	routeExecute = hrtclient.POST[*twicmdproto.ExecuteRequest, *twicmdproto.ExecuteResponse]("/execute")
+
+	routeAutocomplete = hrtclient.POST[*twicmdproto.ArgumentAutocompleteRequest, *twicmdproto.ArgumentAutocompleteResponse]("/autocomplete")
 )
 
 // Service implements [twicmd.Service].
@@ -264,11 +271,21 @@ func (s *Client) Service(ctx context.Context) (*twicmdproto.Service, error) {
 	})
 }
 
+// Reload implements [twicmd.ReloadableService].
+func (s *Client) Reload() {
+	s.cachedService.Renew(0, nil)
+}
+
 // Execute implements [twicmd.Service].
 func (s *Client) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
 	return routeExecute(ctx, s.hrtClient, req)
 }
 
+// Autocomplete implements [twicmd.AutocompletingService].
+func (s *Client) Autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	return routeAutocomplete(ctx, s.hrtClient, req)
+}
+
 var (
 	routeConfigurationValues      = hrtclient.GET[*twicmdcfgpb.OptionsRequest, *twicmdcfgpb.OptionsResponse]("/configuration")
 	routeApplyConfigurationValues = hrtclient.PATCH[*twicmdcfgpb.ApplyRequest, *twicmdcfgpb.ApplyResponse]("/configuration")
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/twipi/twipi/internal/openapi"
 	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
 	"github.com/twipi/twipi/proto/out/twicmdproto"
 	"github.com/twipi/twipi/proto/out/twismsproto"
@@ -17,6 +18,12 @@ import (
 	"libdb.so/hrtproto"
 )
 
+var openapiConfig = openapi.Config{
+	Title:       "twicmd HTTP service",
+	Description: "The API that twid uses to talk to a twicmd service over HTTP. Requests and responses are Protobuf messages encoded as JSON.",
+	Version:     "v0",
+}
+
 // Handler wraps a [twicmd.Service] and turns it into an HTTP server.
 type Handler struct {
 	service  twicmd.Service
@@ -48,15 +55,23 @@ func NewHandler(service twicmd.Service, logger *slog.Logger) *Handler {
 		ErrorWriter: hrt.TextErrorWriter,
 	}))
 
-	r.Get("/", hrt.Wrap(s.getService))
-	r.Get("/messages", s.sseMessages)
-	r.Post("/execute", hrt.Wrap(s.execute))
+	r.Method(http.MethodGet, "/", openapi.Wrap(s.getService, "Describe the service"))
+	r.Method(http.MethodGet, "/messages", &openapi.Endpoint{
+		Handler:  http.HandlerFunc(s.sseMessages),
+		Summary:  "Stream the messages that the service sends",
+		Response: (*twismsproto.Message)(nil).ProtoReflect().Descriptor(),
+		Stream:   true,
+	})
+	r.Method(http.MethodPost, "/execute", openapi.Wrap(s.execute, "Execute a command"))
+	r.Method(http.MethodPost, "/autocomplete", openapi.Wrap(s.autocomplete, "Suggest values for a command argument"))
 
 	r.Route("/configuration", func(r chi.Router) {
-		r.Get("/", hrt.Wrap(s.getConfigurationValues))
-		r.Patch("/", hrt.Wrap(s.applyConfigurationValues))
+		r.Method(http.MethodGet, "/", openapi.Wrap(s.getConfigurationValues, "Get the configuration values of a user"))
+		r.Method(http.MethodPatch, "/", openapi.Wrap(s.applyConfigurationValues, "Change the configuration values of a user"))
 	})
 
+	r.Method(http.MethodGet, "/openapi.json", openapi.Serve(r, openapiConfig))
+
 	return s
 }
 
@@ -79,6 +94,14 @@ func (s *Handler) execute(ctx context.Context, req *twicmdproto.ExecuteRequest)
 	return s.service.Execute(ctx, req)
 }
 
+func (s *Handler) autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	autocompleter, ok := s.service.(twicmd.AutocompletingService)
+	if !ok {
+		return nil, hrt.NewHTTPError(http.StatusNotAcceptable, "service does not support autocompletion")
+	}
+	return autocompleter.Autocomplete(ctx, req)
+}
+
 func (s *Handler) sseMessages(w http.ResponseWriter, r *http.Request) {
 	if _, ok := w.(http.Flusher); !ok {
 		// TODO: consider long polling as a fallback.
@@ -0,0 +1,248 @@
+package twicmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+)
+
+// Built-in commands that change how messages without a service name are
+// handled. Like aliases, they are handled before the message is parsed.
+const (
+	// defaultCommand shows or changes the default service of the sender, e.g.
+	// "/default discord".
+	defaultCommand = "default"
+	// exitCommand leaves the service that was entered by sending its name
+	// alone, e.g. "/discord".
+	exitCommand = "exit"
+)
+
+// DefaultServiceStore stores the default service of each user, keyed by their
+// phone number. Commands of the default service can be sent without the name
+// of the service, e.g. "/send ..." instead of "/discord send ...".
+type DefaultServiceStore interface {
+	// DefaultService returns the name of the default service of the phone
+	// number, or an empty string if it has none.
+	DefaultService(ctx context.Context, phoneNumber string) (string, error)
+	// SetDefaultService sets the default service of the phone number. An
+	// empty name removes it.
+	SetDefaultService(ctx context.Context, phoneNumber, service string) error
+}
+
+// DefaultStickyTimeout is the default time after the last message to a
+// service that was entered before it is left.
+const DefaultStickyTimeout = time.Hour
+
+// StickyOpts configures sticky service mode. Sending only the name of a
+// service, e.g. "/discord", enters it: every following message that isn't a
+// slash command goes to that service, as if it started with "/discord", until
+// "/exit" is sent or the conversation goes quiet.
+type StickyOpts struct {
+	// Disable disables entering services.
+	Disable bool
+	// Timeout is how long a conversation may go without messages before the
+	// entered service is left. If zero, [DefaultStickyTimeout] is used.
+	Timeout time.Duration
+}
+
+// enteredService is a service that a conversation has entered.
+type enteredService struct {
+	name    string
+	expires time.Time
+}
+
+// sticky keeps the services that conversations have entered. The zero value is
+// ready to use.
+type sticky struct {
+	opts     StickyOpts
+	mu       sync.Mutex
+	services map[string]enteredService
+}
+
+func (s *sticky) timeout() time.Duration {
+	if s.opts.Timeout == 0 {
+		return DefaultStickyTimeout
+	}
+	return s.opts.Timeout
+}
+
+// get returns the service that the conversation has entered, if any. Getting
+// the service keeps the conversation in it for longer.
+func (s *sticky) get(key string, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entered, ok := s.services[key]
+	if !ok {
+		return ""
+	}
+	if now.After(entered.expires) {
+		delete(s.services, key)
+		return ""
+	}
+
+	entered.expires = now.Add(s.timeout())
+	s.services[key] = entered
+	return entered.name
+}
+
+// set makes the conversation enter the service. An empty name leaves the
+// entered service.
+func (s *sticky) set(key, service string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if service == "" {
+		delete(s.services, key)
+		return
+	}
+	if s.services == nil {
+		s.services = make(map[string]enteredService)
+	}
+	// Forget conversations that were abandoned.
+	for k, v := range s.services {
+		if now.After(v.expires) {
+			delete(s.services, k)
+		}
+	}
+	s.services[key] = enteredService{
+		name:    service,
+		expires: now.Add(s.timeout()),
+	}
+}
+
+// enteredService returns the service that the conversation has entered, or an
+// empty string if sticky mode is disabled or no service was entered.
+func (d *dispatchContext) enteredService() string {
+	if d.sticky == nil {
+		return ""
+	}
+	return d.sticky.get(conversationKey(d.msg), time.Now())
+}
+
+// builtin executes the text if it is one of the built-in commands that work on
+// the text of messages rather than on parsed commands.
+func (d *dispatchContext) builtin(ctx context.Context, text string) (*twicmdproto.ExecuteResponse, bool, error) {
+	word, args := cutWord(text)
+	name, ok := strings.CutPrefix(word, "/")
+	if !ok {
+		return nil, false, nil
+	}
+
+	switch {
+	case strings.EqualFold(name, aliasCommand) && d.aliases != nil:
+		resp, err := d.manageAliases(ctx, args)
+		return resp, true, err
+
+	case strings.EqualFold(name, defaultCommand) && d.defaults != nil:
+		resp, err := d.manageDefaultService(ctx, args)
+		return resp, true, err
+
+	case strings.EqualFold(name, exitCommand) && d.enteredService() != "":
+		service := d.enteredService()
+		d.sticky.set(conversationKey(d.msg), "", time.Now())
+		return statusResponse(fmt.Sprintf("Left /%s.", service)), true, nil
+
+	case args == "" && d.sticky != nil:
+		if _, ok := d.lookup.Service(name); !ok {
+			return nil, false, nil
+		}
+		d.sticky.set(conversationKey(d.msg), name, time.Now())
+		return statusResponse(fmt.Sprintf(
+			"Entered /%s. Messages now go to it until you send /%s.", name, exitCommand)), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// manageDefaultService executes a default command, which shows or changes the
+// default service of the sender.
+func (d *dispatchContext) manageDefaultService(ctx context.Context, args string) (*twicmdproto.ExecuteResponse, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(args), "/")
+
+	switch strings.ToLower(name) {
+	case "":
+		service, err := d.defaults.DefaultService(ctx, d.msg.From)
+		if err != nil {
+			return nil, err
+		}
+		if service == "" {
+			return statusResponse(fmt.Sprintf(
+				"You have no default service. Send /%s <service> to set one.", defaultCommand)), nil
+		}
+		return statusResponse(fmt.Sprintf(
+			"Your default service is /%s. Send /%s off to remove it.", service, defaultCommand)), nil
+
+	case "off", "none":
+		if err := d.defaults.SetDefaultService(ctx, d.msg.From, ""); err != nil {
+			return nil, err
+		}
+		return statusResponse("You no longer have a default service."), nil
+	}
+
+	if _, ok := d.lookup.Service(name); !ok {
+		return statusResponse(d.unknownService(name)), nil
+	}
+	if err := d.defaults.SetDefaultService(ctx, d.msg.From, name); err != nil {
+		return nil, err
+	}
+	return statusResponse(fmt.Sprintf(
+		"/%s is now your default service, so its commands can be sent without %q.", name, "/"+name)), nil
+}
+
+// implicitService returns the text with the name of the entered service
+// prepended if the text isn't a slash command, or with the name of the entered
+// or default service prepended if the text starts with one of its commands
+// instead of a service.
+func (d *dispatchContext) implicitService(ctx context.Context, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return text, nil
+	}
+	word, _ := cutWord(text)
+
+	entered := d.enteredService()
+	if entered != "" && !strings.HasPrefix(word, "/") {
+		return "/" + entered + " " + text, nil
+	}
+
+	name, ok := strings.CutPrefix(word, "/")
+	if !ok || name == "" || name == HelpService {
+		return text, nil
+	}
+	if _, ok := d.lookup.Service(name); ok {
+		return text, nil
+	}
+
+	service := entered
+	if service == "" && d.defaults != nil {
+		var err error
+		service, err = d.defaults.DefaultService(ctx, d.msg.From)
+		if err != nil {
+			return "", err
+		}
+	}
+	if service == "" {
+		return text, nil
+	}
+
+	resolved, err := d.lookup.Lookup(ctx, service)
+	if err != nil {
+		return "", err
+	}
+	if resolved == nil {
+		// The service may have been removed since it became the default.
+		return text, nil
+	}
+
+	for _, command := range resolved.Description.Commands {
+		if strings.EqualFold(command.Name, name) {
+			return "/" + service + " " + text[1:], nil
+		}
+	}
+	return text, nil
+}
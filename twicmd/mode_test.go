@@ -0,0 +1,93 @@
+package twicmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+)
+
+// memoryDefaultServiceStore is an in-memory [DefaultServiceStore].
+type memoryDefaultServiceStore map[string]string
+
+func (s memoryDefaultServiceStore) DefaultService(ctx context.Context, phoneNumber string) (string, error) {
+	return s[phoneNumber], nil
+}
+
+func (s memoryDefaultServiceStore) SetDefaultService(ctx context.Context, phoneNumber, service string) error {
+	if service == "" {
+		delete(s, phoneNumber)
+	} else {
+		s[phoneNumber] = service
+	}
+	return nil
+}
+
+func TestModes(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	manager := &Manager{
+		Parsers:         []CommandParser{wordsParser{}},
+		Services:        lookup,
+		DefaultServices: memoryDefaultServiceStore{},
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	send := func(text string) *twicmdproto.ExecuteResponse {
+		d := manager.dispatchContext(&twismsproto.Message{
+			From: "+15555550123",
+			To:   "+15555550100",
+			Body: &twismsproto.MessageBody{Text: &twismsproto.TextBody{Text: text}},
+		})
+		d.sticky = &manager.sticky
+
+		resp, err := d.run(ctx)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("/send general hi"))
+		assert.Equal(t,
+			StatusResponse("You have no default service. Send /default <service> to set one."),
+			send("/default"))
+		assert.Equal(t,
+			StatusResponse(`Unknown service "chta", did you mean /chat?`),
+			send("/default chta"))
+		assert.Equal(t,
+			StatusResponse(`/chat is now your default service, so its commands can be sent without "/chat".`),
+			send("/default /chat"))
+		assert.Equal(t, TextResponse("general: hi"), send("/send general hi"))
+		assert.Equal(t, TextResponse("general: hi"), send("/chat send general hi"))
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("send general hi"), "only commands use the default service")
+		assert.Equal(t, StatusResponse("You no longer have a default service."), send("/default off"))
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("/send general hi"))
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		assert.Equal(t, StatusResponse("Entered /chat. Messages now go to it until you send /exit."), send("/chat"))
+		assert.Equal(t, TextResponse("general: hi there"), send("send general hi there"))
+		assert.Equal(t, TextResponse("random: hi"), send("/send random hi"))
+		assert.Equal(t, TextResponse("random: hi"), send("/chat send random hi"))
+		assert.Equal(t, StatusResponse("Left /chat."), send("/exit"))
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("send general hi there"))
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("/exit"))
+	})
+
+	t.Run("sticky timeout", func(t *testing.T) {
+		manager.sticky.opts.Timeout = time.Nanosecond
+		defer func() { manager.sticky.opts.Timeout = 0 }()
+
+		send("/chat")
+		time.Sleep(time.Millisecond)
+		assert.Equal(t, StatusResponse(defaultFallbackText), send("send general hi there"), "entered services are left")
+		assert.Equal(t, 0, len(manager.sticky.services))
+	})
+}
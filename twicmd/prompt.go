@@ -0,0 +1,187 @@
+package twicmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+)
+
+const (
+	// DefaultPromptTimeout is the default time to wait for the answer to a
+	// question about a missing argument.
+	DefaultPromptTimeout = 5 * time.Minute
+	// DefaultCancelKeyword is the default reply that cancels a command whose
+	// arguments are being asked for.
+	DefaultCancelKeyword = "cancel"
+)
+
+// PromptOpts configures asking for missing command arguments. When a parser
+// returns a [MissingArgumentsError], the Manager asks for each missing
+// argument in turn and uses the next reply as its value. A reply that starts
+// with "/" cancels the command and is handled as a new command instead.
+type PromptOpts struct {
+	// Disable disables prompting. Missing arguments are then reported as
+	// errors.
+	Disable bool
+	// Timeout is how long to wait for each answer before forgetting the
+	// command. If zero, [DefaultPromptTimeout] is used.
+	Timeout time.Duration
+	// CancelKeyword is the reply that cancels the command. It is matched
+	// case-insensitively. If empty, [DefaultCancelKeyword] is used.
+	CancelKeyword string
+}
+
+// MissingArgumentsError is returned by parsers when a command is missing
+// required arguments. It carries everything that is needed to ask for them.
+type MissingArgumentsError struct {
+	// Command is the parsed command with the arguments that were given.
+	Command *twicmdproto.Command
+	// Description is the description of the command.
+	Description *twicmdproto.CommandDescription
+	// Missing is the list of the names of the missing arguments, in the order
+	// that they should be asked for. It is never empty.
+	Missing []string
+}
+
+func (e *MissingArgumentsError) Error() string {
+	return fmt.Sprintf("missing required argument %q", e.Missing[0])
+}
+
+// pendingPrompt is a command that is waiting for the answer to a question
+// about one of its arguments.
+type pendingPrompt struct {
+	command     *twicmdproto.Command
+	description *twicmdproto.CommandDescription
+	// missing is the list of arguments that are left to ask for. The first
+	// one is the one being asked for.
+	missing []string
+	expires time.Time
+}
+
+// prompts keeps the pending prompts of all conversations. The zero value is
+// ready to use.
+type prompts struct {
+	opts    PromptOpts
+	mu      sync.Mutex
+	pending map[string]*pendingPrompt
+}
+
+// conversationKey returns the key of the conversation that the message belongs
+// to.
+func conversationKey(msg *twismsproto.Message) string {
+	return msg.From + " " + msg.To
+}
+
+// take removes and returns the unexpired pending prompt of the conversation,
+// if any.
+func (p *prompts) take(key string, now time.Time) *pendingPrompt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prompt, ok := p.pending[key]
+	if !ok {
+		return nil
+	}
+	delete(p.pending, key)
+
+	if now.After(prompt.expires) {
+		return nil
+	}
+	return prompt
+}
+
+// put stores the pending prompt of the conversation and returns the question
+// to ask.
+func (p *prompts) put(key string, prompt *pendingPrompt, now time.Time) string {
+	timeout := p.opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultPromptTimeout
+	}
+	prompt.expires = now.Add(timeout)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[string]*pendingPrompt)
+	}
+	// Forget conversations that were abandoned.
+	for k, v := range p.pending {
+		if now.After(v.expires) {
+			delete(p.pending, k)
+		}
+	}
+	p.pending[key] = prompt
+
+	return prompt.question()
+}
+
+func (p *prompts) cancelKeyword() string {
+	if p.opts.CancelKeyword != "" {
+		return p.opts.CancelKeyword
+	}
+	return DefaultCancelKeyword
+}
+
+// question returns the question that asks for the first missing argument.
+func (p *pendingPrompt) question() string {
+	name := p.missing[0]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "What %s?", strings.ReplaceAll(name, "_", " "))
+	if arg := p.description.Arguments[name]; arg.GetDescription() != "" {
+		b.WriteString("\n")
+		b.WriteString(arg.Description)
+	}
+	return b.String()
+}
+
+// startPrompt starts asking for the arguments that the command is missing and
+// returns the first question.
+func (d *dispatchContext) startPrompt(missing *MissingArgumentsError) *twicmdproto.ExecuteResponse {
+	prompt := &pendingPrompt{
+		command:     missing.Command,
+		description: missing.Description,
+		missing:     missing.Missing,
+	}
+
+	d.logger.Debug(
+		"prompting for missing arguments",
+		"service", missing.Command.Service,
+		"command", missing.Command.Command,
+		"missing", missing.Missing)
+
+	question := d.prompts.put(conversationKey(d.msg), prompt, time.Now())
+	return statusResponse(fmt.Sprintf("%s\n(Reply %q to cancel.)", question, d.prompts.cancelKeyword()))
+}
+
+// answerPrompt uses the message as the answer to the pending prompt. It
+// executes the command once all of its arguments are known.
+func (d *dispatchContext) answerPrompt(ctx context.Context, prompt *pendingPrompt) (*twicmdproto.ExecuteResponse, error) {
+	answer := strings.TrimSpace(d.msg.GetBody().GetText().GetText())
+
+	if strings.EqualFold(answer, d.prompts.cancelKeyword()) {
+		return statusResponse("Cancelled."), nil
+	}
+
+	if answer != "" {
+		prompt.command.Arguments = append(prompt.command.Arguments, &twicmdproto.CommandArgument{
+			Name:  prompt.missing[0],
+			Value: answer,
+		})
+		prompt.missing = prompt.missing[1:]
+	}
+
+	if len(prompt.missing) > 0 {
+		// Ask again if the answer was empty.
+		question := d.prompts.put(conversationKey(d.msg), prompt, time.Now())
+		return statusResponse(question), nil
+	}
+
+	return d.execute(ctx, prompt.command)
+}
@@ -0,0 +1,105 @@
+package twicmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+)
+
+var sendCommand = &twicmdproto.CommandDescription{
+	Name: "send",
+	Arguments: map[string]*twicmdproto.CommandArgumentDescription{
+		"channel": {Required: true, Description: "The channel to send to."},
+		"message": {Required: true},
+	},
+}
+
+// missingParser always returns a send command without arguments.
+type missingParser struct{}
+
+func (missingParser) Name() string { return "missing" }
+
+func (missingParser) Parse(context.Context, *ServiceLookup, *twismsproto.MessageBody) (*twicmdproto.Command, error) {
+	return nil, &MissingArgumentsError{
+		Command:     &twicmdproto.Command{Service: "chat", Command: "send"},
+		Description: sendCommand,
+		Missing:     []string{"channel", "message"},
+	}
+}
+
+// echoService replies with the arguments of the command.
+type echoService struct{}
+
+func (echoService) Name() string { return "chat" }
+
+func (echoService) Service(context.Context) (*twicmdproto.Service, error) {
+	return &twicmdproto.Service{
+		Name:     "chat",
+		Commands: []*twicmdproto.CommandDescription{sendCommand},
+	}, nil
+}
+
+func (echoService) Execute(ctx context.Context, req *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	args := MapArguments(req.Command.Arguments)
+	return TextResponse(args["channel"] + ": " + args["message"]), nil
+}
+
+func (echoService) SubscribeMessages(chan<- *twismsproto.Message, *twismsproto.MessageFilters) {}
+
+func (echoService) UnsubscribeMessages(chan<- *twismsproto.Message) {}
+
+func TestPrompts(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	manager := &Manager{
+		Parsers:  []CommandParser{missingParser{}},
+		Services: lookup,
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	send := func(text string) *twicmdproto.ExecuteResponse {
+		d := manager.dispatchContext(&twismsproto.Message{
+			From: "+15555550123",
+			To:   "+15555550100",
+			Body: &twismsproto.MessageBody{Text: &twismsproto.TextBody{Text: text}},
+		})
+		d.prompts = &manager.prompts
+
+		resp, err := d.run(ctx)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t,
+		StatusResponse("What channel?\nThe channel to send to.\n(Reply \"cancel\" to cancel.)"),
+		send("/chat send"))
+	assert.Equal(t, StatusResponse("What message?"), send("general"))
+	assert.Equal(t, StatusResponse("What message?"), send("  "))
+	assert.Equal(t, TextResponse("general: hello there"), send("hello there"))
+
+	send("/chat send")
+	assert.Equal(t, StatusResponse("Cancelled."), send("Cancel"))
+
+	send("/chat send")
+	send("general")
+	assert.Equal(t,
+		StatusResponse("What channel?\nThe channel to send to.\n(Reply \"cancel\" to cancel.)"),
+		send("/chat send"),
+		"slash commands cancel the prompt")
+
+	manager.prompts.opts.Timeout = time.Nanosecond
+	send("/chat send")
+	time.Sleep(time.Millisecond)
+	assert.Equal(t,
+		StatusResponse("What channel?\nThe channel to send to.\n(Reply \"cancel\" to cancel.)"),
+		send("general"),
+		"expired prompts are forgotten")
+}
@@ -3,6 +3,7 @@ package twicmd
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/twipi/twipi/internal/xiter"
@@ -44,6 +45,73 @@ type ConfigurableService interface {
 	ApplyConfigurationValues(context.Context, *twicmdcfgpb.ApplyRequest) (*twicmdcfgpb.ApplyResponse, error)
 }
 
+// AutocompletingService describes a command service that can suggest values
+// for command arguments while the user is typing.
+type AutocompletingService interface {
+	Service
+	// Autocomplete returns suggestions for the argument named in the request.
+	Autocomplete(context.Context, *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error)
+}
+
+// ReloadableService describes a command service that caches things about
+// itself, such as its description.
+type ReloadableService interface {
+	Service
+	// Reload discards everything cached about the service, so that it is
+	// fetched again when it is next needed.
+	Reload()
+}
+
+// Capabilities lists the optional interfaces that a service supports.
+type Capabilities struct {
+	// Configurable is true if the service supports [ConfigurableService].
+	Configurable bool
+	// Autocompleting is true if the service supports [AutocompletingService].
+	Autocompleting bool
+}
+
+// CapableService describes a command service that implements the optional
+// interfaces but only knows at runtime whether it actually supports them, such
+// as a service running in another process. Use [AsConfigurable] and
+// [AsAutocompleting] instead of type assertions to respect it.
+type CapableService interface {
+	Service
+	// Capabilities returns the optional interfaces that the service supports.
+	Capabilities(context.Context) (Capabilities, error)
+}
+
+// AsConfigurable returns the service as a [ConfigurableService] if it supports
+// it.
+func AsConfigurable(ctx context.Context, service Service) (ConfigurableService, bool) {
+	configurable, ok := service.(ConfigurableService)
+	if !ok {
+		return nil, false
+	}
+	if capable, ok := service.(CapableService); ok {
+		caps, err := capable.Capabilities(ctx)
+		if err != nil || !caps.Configurable {
+			return nil, false
+		}
+	}
+	return configurable, true
+}
+
+// AsAutocompleting returns the service as an [AutocompletingService] if it
+// supports it.
+func AsAutocompleting(ctx context.Context, service Service) (AutocompletingService, bool) {
+	autocompleter, ok := service.(AutocompletingService)
+	if !ok {
+		return nil, false
+	}
+	if capable, ok := service.(CapableService); ok {
+		caps, err := capable.Capabilities(ctx)
+		if err != nil || !caps.Autocompleting {
+			return nil, false
+		}
+	}
+	return autocompleter, true
+}
+
 // validateService validates the twicmd service.
 // It is meant to be called by implementations of
 // [CommandParser.RegisterService], but the parser may also do additional
@@ -74,9 +142,11 @@ func validateService(service *twicmdproto.Service) error {
 }
 
 // ServiceLookup provides ways to lookup services, which can be arbitrarily
-// removed and added.
+// removed and added. Services can also be disabled, which hides them from
+// everything but [ServiceLookup.RegisteredServices].
 type ServiceLookup struct {
 	services *xsync.MapOf[string, Service]
+	disabled *xsync.MapOf[string, struct{}]
 }
 
 // TODO: convert the local map to a ServiceRegistry interface.
@@ -85,21 +155,68 @@ type ServiceLookup struct {
 func NewServiceLookup() *ServiceLookup {
 	return &ServiceLookup{
 		services: xsync.NewMapOf[string, Service](),
+		disabled: xsync.NewMapOf[string, struct{}](),
+	}
+}
+
+// Subset returns a new lookup with only the named services of l. Both lookups
+// share which services are disabled, so disabling a service in l also hides it
+// from the subset.
+func (l *ServiceLookup) Subset(names []string) (*ServiceLookup, error) {
+	subset := &ServiceLookup{
+		services: xsync.NewMapOf[string, Service](),
+		disabled: l.disabled,
+	}
+	for _, name := range names {
+		service, ok := l.services.Load(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		subset.services.Store(name, service)
 	}
+	return subset, nil
 }
 
 // Register registers a service for the command parser.
-// If the service already exists, it will be replaced.
-func (l *ServiceLookup) Register(service Service) {
+// If the service already exists, it will be replaced. Services cannot be named
+// after the built-in [HelpService], since they could never be reached.
+func (l *ServiceLookup) Register(service Service) error {
+	if service.Name() == HelpService {
+		return fmt.Errorf("service name %q is reserved", HelpService)
+	}
 	l.services.Store(service.Name(), service)
+	return nil
 }
 
-// Service returns a service by its name.
+// Service returns an enabled service by its name.
 func (l *ServiceLookup) Service(name string) (Service, bool) {
+	if l.Disabled(name) {
+		return nil, false
+	}
 	service, ok := l.services.Load(name)
 	return service, ok
 }
 
+// SetDisabled disables or enables the registered service with the given name.
+// It returns false if there is no such service.
+func (l *ServiceLookup) SetDisabled(name string, disabled bool) bool {
+	if _, ok := l.services.Load(name); !ok {
+		return false
+	}
+	if disabled {
+		l.disabled.Store(name, struct{}{})
+	} else {
+		l.disabled.Delete(name)
+	}
+	return true
+}
+
+// Disabled returns true if the service with the given name is disabled.
+func (l *ServiceLookup) Disabled(name string) bool {
+	_, disabled := l.disabled.Load(name)
+	return disabled
+}
+
 // ResolvedService is a tuple of a service and its description.
 type ResolvedService struct {
 	Service     Service
@@ -109,7 +226,7 @@ type ResolvedService struct {
 // Lookup looks up a service by its name
 // If the service is not found, nil on each or both is returned.
 func (l *ServiceLookup) Lookup(ctx context.Context, serviceName string) (*ResolvedService, error) {
-	service, ok := l.services.Load(serviceName)
+	service, ok := l.Service(serviceName)
 	if !ok {
 		return nil, nil
 	}
@@ -135,15 +252,50 @@ type ServiceCommandTuple struct {
 	Command *twicmdproto.CommandDescription
 }
 
-// LookupCommand looks up a command by its name.
+// LookupCommand looks up a command by its name. If the service or the command
+// does not exist, an [*UnknownServiceError] or [*UnknownCommandError] that
+// suggests similar names is returned.
 func (l *ServiceLookup) LookupCommand(ctx context.Context, serviceName, commandName string) (*ServiceCommandTuple, error) {
+	return l.MatchCommand(ctx, serviceName, commandName, SuggestOpts{})
+}
+
+// MatchCommand is like [ServiceLookup.LookupCommand], except misspelled names
+// are matched against the registered ones as configured by opts.
+func (l *ServiceLookup) MatchCommand(ctx context.Context, serviceName, commandName string, opts SuggestOpts) (*ServiceCommandTuple, error) {
 	service, err := l.Lookup(ctx, serviceName)
 	if err != nil {
 		return nil, err
 	}
 
+	if service == nil {
+		accepted, suggestions := opts.match(serviceName, l.serviceNames())
+		if accepted == "" {
+			return nil, &UnknownServiceError{Name: serviceName, Suggestions: suggestions}
+		}
+
+		service, err = l.Lookup(ctx, accepted)
+		if err != nil {
+			return nil, err
+		}
+		if service == nil {
+			// The service was removed in the meantime.
+			return nil, &UnknownServiceError{Name: serviceName}
+		}
+	}
+
+	names := make([]string, len(service.Description.Commands))
+	for i, cmd := range service.Description.Commands {
+		names[i] = cmd.Name
+	}
+
+	accepted := commandName
+	var suggestions []string
+	if !slices.Contains(names, commandName) {
+		accepted, suggestions = opts.match(commandName, names)
+	}
+
 	for _, cmd := range service.Description.Commands {
-		if cmd.Name == commandName {
+		if accepted != "" && cmd.Name == accepted {
 			return &ServiceCommandTuple{
 				ResolvedService: *service,
 				Command:         cmd,
@@ -151,11 +303,42 @@ func (l *ServiceLookup) LookupCommand(ctx context.Context, serviceName, commandN
 		}
 	}
 
-	return nil, fmt.Errorf("unknown command %q for service %q", commandName, serviceName)
+	return nil, &UnknownCommandError{
+		Service:     service.Description.Name,
+		Name:        commandName,
+		Suggestions: suggestions,
+	}
+}
+
+// suggestServices returns the names of the services that may have been meant
+// by the unknown service name, best first.
+func (l *ServiceLookup) suggestServices(name string) []string {
+	_, suggestions := SuggestOpts{}.match(name, l.serviceNames())
+	return suggestions
 }
 
-// AllServices returns all registered services.
+func (l *ServiceLookup) serviceNames() []string {
+	var names []string
+	for _, service := range l.AllServices() {
+		names = append(names, service.Name())
+	}
+	return names
+}
+
+// AllServices returns all enabled services.
 func (l *ServiceLookup) AllServices() []Service {
+	var services []Service
+	l.services.Range(func(name string, service Service) bool {
+		if !l.Disabled(name) {
+			services = append(services, service)
+		}
+		return true
+	})
+	return services
+}
+
+// RegisteredServices returns all registered services, including disabled ones.
+func (l *ServiceLookup) RegisteredServices() []Service {
 	var services []Service
 	l.services.Range(func(_ string, service Service) bool {
 		services = append(services, service)
@@ -170,7 +353,11 @@ func (l *ServiceLookup) ResolveAllServices(ctx context.Context) xiter.Seq2[*Reso
 	// TODO: parallelize me!
 	return func(yield func(*ResolvedService, error) bool) bool {
 		ok := true
-		l.services.Range(func(_ string, service Service) bool {
+		l.services.Range(func(name string, service Service) bool {
+			if l.Disabled(name) {
+				return true
+			}
+
 			desc, err := service.Service(ctx)
 			if err != nil {
 				yield(nil, fmt.Errorf("failed to resolve service %q: %w", service.Name(), err))
@@ -0,0 +1,70 @@
+package twicmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestServiceLookupDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	assert.False(t, lookup.SetDisabled("nope", true), "unknown service disabled")
+	assert.True(t, lookup.SetDisabled("chat", true), "service not disabled")
+	assert.True(t, lookup.Disabled("chat"))
+
+	_, ok := lookup.Service("chat")
+	assert.False(t, ok, "disabled service found")
+	resolved, err := lookup.Lookup(ctx, "chat")
+	assert.NoError(t, err)
+	assert.Zero(t, resolved)
+	assert.Zero(t, lookup.AllServices())
+	assert.Equal(t, 1, len(lookup.RegisteredServices()))
+
+	_, err = lookup.LookupCommand(ctx, "chat", "send")
+	assert.IsError(t, err, ErrUnknownService)
+
+	assert.True(t, lookup.SetDisabled("chat", false), "service not enabled")
+	_, ok = lookup.Service("chat")
+	assert.True(t, ok, "enabled service not found")
+}
+
+// namedService is an echoService with a different name.
+type namedService struct {
+	echoService
+	name string
+}
+
+func (s namedService) Name() string { return s.name }
+
+func TestServiceLookupRegister(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+	assert.Error(t, lookup.Register(namedService{name: HelpService}))
+
+	_, ok := lookup.Service(HelpService)
+	assert.False(t, ok, "reserved service registered")
+}
+
+func TestServiceLookupSubset(t *testing.T) {
+	lookup := NewServiceLookup()
+	assert.NoError(t, lookup.Register(echoService{}))
+
+	_, err := lookup.Subset([]string{"chat", "nope"})
+	assert.Error(t, err)
+
+	subset, err := lookup.Subset([]string{"chat"})
+	assert.NoError(t, err)
+
+	assert.True(t, lookup.SetDisabled("chat", true), "service not disabled")
+	_, ok := subset.Service("chat")
+	assert.False(t, ok, "disabled service found in subset")
+
+	assert.True(t, subset.SetDisabled("chat", false), "service not enabled")
+	_, ok = lookup.Service("chat")
+	assert.True(t, ok, "enabled service not found")
+}
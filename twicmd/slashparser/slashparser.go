@@ -3,9 +3,9 @@ package slashparser
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"log/slog"
 	"strconv"
 	"strings"
 
@@ -17,11 +17,40 @@ import (
 	"mvdan.cc/sh/v3/syntax"
 )
 
+// Config is the configuration of the slash parser module.
+type Config struct {
+	// Suggestions configures how misspelled service and command names are
+	// handled.
+	Suggestions SuggestionsConfig `json:"suggestions,omitempty"`
+}
+
+// SuggestionsConfig configures how misspelled service and command names are
+// handled. See [twicmd.SuggestOpts].
+type SuggestionsConfig struct {
+	// MaxDistance is the maximum edit distance of suggested names. It
+	// defaults to 2. If negative, only prefixes are suggested.
+	MaxDistance int `json:"max_distance,omitempty"`
+	// MinPrefixLength is the minimum length of a name that is matched as a
+	// prefix. It defaults to 3.
+	MinPrefixLength int `json:"min_prefix_length,omitempty"`
+	// AutoAccept accepts names that differ only in case or that are an
+	// unambiguous prefix instead of suggesting them.
+	AutoAccept bool `json:"auto_accept,omitempty"`
+}
+
 func init() {
 	twid.RegisterTwicmdParser(twid.TwicmdParser{
 		Name: "slash",
-		New: func(cfg json.RawMessage, logger *slog.Logger) (twicmd.CommandParser, error) {
-			return NewParser(), nil
+		New: func(cfg json.RawMessage, mctx twid.ModuleContext) (twicmd.CommandParser, error) {
+			var config Config
+			if err := json.Unmarshal(cfg, &config); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal slash parser config: %w", err)
+			}
+			return NewParserWithOpts(twicmd.SuggestOpts{
+				MaxDistance:     config.Suggestions.MaxDistance,
+				MinPrefixLength: config.Suggestions.MinPrefixLength,
+				AutoAccept:      config.Suggestions.AutoAccept,
+			}), nil
 		},
 	})
 }
@@ -32,11 +61,18 @@ var shellParser = syntax.NewParser(
 
 // Parser is a command parser that parses slash commands.
 type Parser struct {
+	suggest twicmd.SuggestOpts
 }
 
 // NewParser creates a new Parser.
 func NewParser() *Parser {
-	return (*Parser)(nil)
+	return &Parser{}
+}
+
+// NewParserWithOpts creates a new Parser that matches misspelled service and
+// command names as configured.
+func NewParserWithOpts(suggest twicmd.SuggestOpts) *Parser {
+	return &Parser{suggest: suggest}
 }
 
 func (p *Parser) Name() string {
@@ -49,6 +85,10 @@ func (p *Parser) Parse(ctx context.Context, lookup *twicmd.ServiceLookup, body *
 		return nil, fmt.Errorf("empty message body")
 	}
 
+	if help, err := p.parseHelp(body.Text.Text); help != nil || err != nil {
+		return help, err
+	}
+
 	startingWords, rest, err := p.parseNWords(body.Text.Text, 2)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse command start: %w", err)
@@ -61,7 +101,7 @@ func (p *Parser) Parse(ctx context.Context, lookup *twicmd.ServiceLookup, body *
 	serviceName := startingWords[0][1:]
 	commandName := startingWords[1]
 
-	result, err := lookup.LookupCommand(ctx, serviceName, commandName)
+	result, err := lookup.MatchCommand(ctx, serviceName, commandName, p.suggest)
 	if err != nil {
 		return nil, err
 	}
@@ -71,11 +111,52 @@ func (p *Parser) Parse(ctx context.Context, lookup *twicmd.ServiceLookup, body *
 		return nil, fmt.Errorf("failed to parse command %q: %w", result.Command.Name, err)
 	}
 
-	return &twicmdproto.Command{
+	command := &twicmdproto.Command{
 		Service:   result.Description.Name,
 		Command:   result.Command.Name,
 		Arguments: arguments,
-	}, nil
+	}
+
+	if missing := twicmd.MissingArguments(result.Command, arguments); len(missing) > 0 {
+		return nil, fmt.Errorf("failed to parse command %q: %w", result.Command.Name, &twicmd.MissingArgumentsError{
+			Command:     command,
+			Description: result.Command,
+			Missing:     missing,
+		})
+	}
+
+	return command, nil
+}
+
+const helpUsage = "usage: /help [service [command]] [page]"
+
+// parseHelp parses "/help [service [command]] [page]" into a
+// [twicmd.HelpCommand]. It returns nil if the text isn't asking for help.
+func (p *Parser) parseHelp(text string) (*twicmdproto.Command, error) {
+	if fields := strings.Fields(text); len(fields) == 0 || fields[0] != "/"+twicmd.HelpService {
+		return nil, nil
+	}
+
+	words, _, err := p.parseNWords(text, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse help: %w", err)
+	}
+	words = words[1:]
+
+	var page int
+	if len(words) > 0 {
+		if n, err := strconv.Atoi(words[len(words)-1]); err == nil {
+			page = n
+			words = words[:len(words)-1]
+		}
+	}
+
+	if len(words) > 2 || page < 0 {
+		return nil, errors.New(helpUsage)
+	}
+	words = append(words, "", "")
+
+	return twicmd.HelpCommand(words[0], words[1], page), nil
 }
 
 func (p *Parser) parseCommand(
@@ -107,12 +188,22 @@ func (p *Parser) parseCommand(
 
 		words, rest, err := p.parseNWords(args, len(positionalArgs))
 		if err != nil {
-			return nil, fmt.Errorf("failed to split positional arguments: %w", err)
+			var countErr *wordCountError
+			if !errors.As(err, &countErr) {
+				return nil, fmt.Errorf("failed to split positional arguments: %w", err)
+			}
+			// Missing arguments are reported by the caller, which may ask
+			// for them.
+			words, rest = countErr.words, ""
 		}
 
 		for i, name := range positionalArgs {
 			arg := command.Arguments[name]
 
+			if i >= len(words) {
+				break
+			}
+
 			if err := assertHintedValue(words[i], arg.Hint); err != nil {
 				return nil, fmt.Errorf("invalid value %q for argument %q: %w", args[0], name, err)
 			}
@@ -120,10 +211,14 @@ func (p *Parser) parseCommand(
 			appendArgument(name, words[i])
 		}
 
-		if command.ArgumentTrailing {
+		if command.ArgumentTrailing && len(words) == len(positionalArgs) {
 			name := command.ArgumentPositions[len(command.ArgumentPositions)-1]
 			arg := command.Arguments[name]
 
+			if rest == "" && arg.Required {
+				return arguments, nil
+			}
+
 			if err := assertHintedValue(rest, arg.Hint); err != nil {
 				return nil, fmt.Errorf("invalid value %q for argument %q: %w", rest, name, err)
 			}
@@ -161,13 +256,6 @@ func (p *Parser) parseCommand(
 		}
 	}
 
-	// Verify that all required arguments are present.
-	for name, argDesc := range command.Arguments {
-		if argDesc.Required && argumentMap[name] == "" {
-			return nil, fmt.Errorf("missing required argument %q", name)
-		}
-	}
-
 	return arguments, nil
 }
 
@@ -190,10 +278,10 @@ func (p *Parser) parseNWords(s string, n int) ([]string, string, error) {
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to split words: %w", err)
 	}
-	if n > 0 && len(words) < n {
-		return nil, "", fmt.Errorf("expected %d words, got %d", n, len(words))
-	}
 	if len(words) == 0 {
+		if n > 0 {
+			return nil, "", &wordCountError{n, nil}
+		}
 		return nil, "", nil
 	}
 
@@ -206,12 +294,27 @@ func (p *Parser) parseNWords(s string, n int) ([]string, string, error) {
 		lits[i] = lit
 	}
 
+	if n > 0 && len(words) < n {
+		return nil, "", &wordCountError{n, lits}
+	}
+
 	last := words[len(words)-1]
 	s = strings.TrimSpace(s[last.End().Offset():])
 
 	return lits, s, nil
 }
 
+// wordCountError is returned by parseNWords if there are fewer words than
+// expected. It carries the words that were parsed.
+type wordCountError struct {
+	want  int
+	words []string
+}
+
+func (e *wordCountError) Error() string {
+	return fmt.Sprintf("expected %d words, got %d", e.want, len(e.words))
+}
+
 // shLiteral returns the literal string representation of the given shell word.
 func shLiteral(word *syntax.Word) (string, error) {
 	return expand.Literal(nil, word)
@@ -2,6 +2,7 @@ package slashparser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -72,6 +73,18 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "parse help",
+			body:   "/help discord send 2",
+			lookup: mustLookupWithServices("./testdata/test_service.txtpb"),
+			result: twicmd.HelpCommand("discord", "send", 2),
+		},
+		{
+			name:   "parse help without arguments",
+			body:   "/help",
+			lookup: mustLookupWithServices("./testdata/test_service.txtpb"),
+			result: twicmd.HelpCommand("", "", 0),
+		},
 	}
 
 	ctx := context.Background()
@@ -92,6 +105,86 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestParserMissingArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		lookup    *twicmd.ServiceLookup
+		arguments []*twicmdproto.CommandArgument
+		missing   []string
+	}{
+		{
+			name:   "positional",
+			body:   "/discord send DiscordGophers",
+			lookup: mustLookupWithServices("./testdata/test_service.txtpb"),
+			arguments: []*twicmdproto.CommandArgument{
+				{Name: "guild", Value: "DiscordGophers"},
+			},
+			missing: []string{"channel", "message"},
+		},
+		{
+			name:   "positional trailing",
+			body:   "/discord send DiscordGophers offtopic",
+			lookup: mustLookupWithServices("./testdata/test_service.txtpb"),
+			arguments: []*twicmdproto.CommandArgument{
+				{Name: "guild", Value: "DiscordGophers"},
+				{Name: "channel", Value: "offtopic"},
+			},
+			missing: []string{"message"},
+		},
+		{
+			name:   "named",
+			body:   "/discord send channel=offtopic",
+			lookup: mustLookupWithServices("./testdata/test_service_named.txtpb"),
+			arguments: []*twicmdproto.CommandArgument{
+				{Name: "channel", Value: "offtopic"},
+			},
+			missing: []string{"guild", "message"},
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := &twismsproto.MessageBody{
+				Text: &twismsproto.TextBody{Text: test.body},
+			}
+
+			_, err := NewParser().Parse(ctx, test.lookup, body)
+
+			var missing *twicmd.MissingArgumentsError
+			assert.True(t, errors.As(err, &missing), "expected missing arguments error, got %v", err)
+			assert.Equal(t, test.missing, missing.Missing)
+
+			if diff := cmp.Diff(test.arguments, missing.Command.Arguments, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected arguments (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParserSuggestions(t *testing.T) {
+	ctx := context.Background()
+	lookup := mustLookupWithServices("./testdata/test_service.txtpb")
+	body := func(text string) *twismsproto.MessageBody {
+		return &twismsproto.MessageBody{Text: &twismsproto.TextBody{Text: text}}
+	}
+
+	_, err := NewParser().Parse(ctx, lookup, body("/discrod send a b c"))
+	assert.EqualError(t, err, `unknown service "discrod" (did you mean /discord?)`)
+	assert.IsError(t, err, twicmd.ErrUnknownService)
+
+	_, err = NewParser().Parse(ctx, lookup, body("/discord sned a b c"))
+	assert.EqualError(t, err, `unknown command "sned" for service "discord" (did you mean /discord send?)`)
+
+	parser := NewParserWithOpts(twicmd.SuggestOpts{AutoAccept: true})
+	command, err := parser.Parse(ctx, lookup, body("/Disc sen a b c"))
+	assert.NoError(t, err)
+	assert.Equal(t, "discord", command.Service)
+	assert.Equal(t, "send", command.Command)
+}
+
 /*
 func FuzzParser_Positional(f *testing.F) {
 	ctx := context.Background()
@@ -158,7 +251,9 @@ func mustLookupWithServices(serviceFiles ...string) *twicmd.ServiceLookup {
 	lookup := twicmd.NewServiceLookup()
 	for _, file := range serviceFiles {
 		service := mustReadPrototext[*twicmdproto.Service](file)
-		lookup.Register(&testService{service.Name, service})
+		if err := lookup.Register(&testService{service.Name, service}); err != nil {
+			panic(fmt.Sprintln("failed to register service:", err))
+		}
 	}
 	return lookup
 }
@@ -0,0 +1,203 @@
+package twicmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultMaxSuggestionDistance is the default maximum edit distance
+	// between a misspelled name and the names suggested for it.
+	DefaultMaxSuggestionDistance = 2
+	// DefaultMinPrefixLength is the default minimum length of a prefix that
+	// is matched against names.
+	DefaultMinPrefixLength = 3
+	// maxSuggestions is the maximum number of names suggested at once.
+	maxSuggestions = 3
+)
+
+// SuggestOpts configures how misspelled service and command names are
+// matched against the registered ones. The zero value suggests names but
+// never accepts them on the user's behalf.
+type SuggestOpts struct {
+	// MaxDistance is the maximum edit distance between a misspelled name and
+	// the names suggested for it. Swapping two adjacent letters counts as one
+	// edit. If zero, [DefaultMaxSuggestionDistance] is used. If negative,
+	// only prefixes are suggested.
+	MaxDistance int
+	// MinPrefixLength is the minimum length of a name that is matched as the
+	// prefix of other names. If zero, [DefaultMinPrefixLength] is used.
+	MinPrefixLength int
+	// AutoAccept makes names that differ only in case or that are the prefix
+	// of exactly one name resolve to that name instead of failing.
+	AutoAccept bool
+}
+
+func (o SuggestOpts) maxDistance() int {
+	if o.MaxDistance == 0 {
+		return DefaultMaxSuggestionDistance
+	}
+	return max(o.MaxDistance, -1)
+}
+
+func (o SuggestOpts) minPrefixLength() int {
+	if o.MinPrefixLength <= 0 {
+		return DefaultMinPrefixLength
+	}
+	return o.MinPrefixLength
+}
+
+// match matches the unknown name against the candidates. It returns the
+// candidate to use instead if it can be accepted, and otherwise the candidates
+// to suggest, best first.
+func (o SuggestOpts) match(name string, candidates []string) (accepted string, suggestions []string) {
+	name = strings.ToLower(name)
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	var matches []scored
+	var prefixOf []string
+
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		if lower == name && o.AutoAccept {
+			return candidate, nil
+		}
+
+		prefix := len(name) >= o.minPrefixLength() && strings.HasPrefix(lower, name)
+		if prefix {
+			prefixOf = append(prefixOf, candidate)
+		}
+
+		// Names shorter than the distance would match anything.
+		distance := editDistance(name, lower)
+		if prefix || (distance <= o.maxDistance() && distance < len(name)) {
+			matches = append(matches, scored{candidate, distance})
+		}
+	}
+
+	if o.AutoAccept && len(prefixOf) == 1 {
+		return prefixOf[0], nil
+	}
+
+	slices.SortFunc(matches, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), strings.Compare(a.name, b.name))
+	})
+	for i, match := range matches {
+		if i == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, match.name)
+	}
+
+	return "", suggestions
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, which is the Levenshtein distance that also counts swapping two adjacent
+// characters as one edit.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	// rows holds the last three rows of the distance matrix.
+	var rows [3][]int
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+	}
+	for j := range rows[1] {
+		rows[1][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		prev2, prev, cur := rows[0], rows[1], rows[2]
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		rows[0], rows[1], rows[2] = prev, cur, prev2
+	}
+
+	return rows[1][len(t)]
+}
+
+// UnknownServiceError is returned when a command names a service that does not
+// exist. It matches [ErrUnknownService].
+type UnknownServiceError struct {
+	Name string
+	// Suggestions is the list of the names of services that may have been
+	// meant instead, best first.
+	Suggestions []string
+}
+
+func (e *UnknownServiceError) Error() string {
+	msg := fmt.Sprintf("unknown service %q", e.Name)
+	if suggestion := didYouMean(e.Suggestions, slashService); suggestion != "" {
+		msg += " (" + suggestion + ")"
+	}
+	return msg
+}
+
+func (e *UnknownServiceError) Is(target error) bool {
+	return target == ErrUnknownService
+}
+
+// UnknownCommandError is returned when a command names a command that its
+// service does not have. It matches [ErrUnknownCommand].
+type UnknownCommandError struct {
+	Service string
+	Name    string
+	// Suggestions is the list of the names of commands of the service that
+	// may have been meant instead, best first.
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	msg := fmt.Sprintf("unknown command %q for service %q", e.Name, e.Service)
+	if suggestion := didYouMean(e.Suggestions, slashCommand(e.Service)); suggestion != "" {
+		msg += " (" + suggestion + ")"
+	}
+	return msg
+}
+
+func (e *UnknownCommandError) Is(target error) bool {
+	return target == ErrUnknownCommand
+}
+
+// didYouMean asks whether one of the suggestions, formatted with format, was
+// meant. It returns an empty string if there are no suggestions.
+func didYouMean(suggestions []string, format func(string) string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	formatted := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		formatted[i] = format(suggestion)
+	}
+
+	return fmt.Sprintf("did you mean %s?", strings.Join(formatted, " or "))
+}
+
+// slashService formats the name of a service the way it is typed.
+func slashService(name string) string {
+	return "/" + name
+}
+
+// slashCommand returns a function that formats the name of a command of the
+// service the way it is typed.
+func slashCommand(service string) func(string) string {
+	return func(name string) string {
+		return "/" + service + " " + name
+	}
+}
@@ -0,0 +1,105 @@
+package twicmd
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"send", "send", 0},
+		{"sned", "send", 1},
+		{"discrod", "discord", 1},
+		{"sen", "send", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"héllo", "hello", 1},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.distance, editDistance(test.a, test.b), "%q -> %q", test.a, test.b)
+		assert.Equal(t, test.distance, editDistance(test.b, test.a), "%q -> %q", test.b, test.a)
+	}
+}
+
+func TestSuggestOpts(t *testing.T) {
+	candidates := []string{"discord", "discover", "weather", "send", "search"}
+
+	tests := []struct {
+		name        string
+		opts        SuggestOpts
+		input       string
+		accepted    string
+		suggestions []string
+	}{
+		{
+			name:        "typo",
+			input:       "discrod",
+			suggestions: []string{"discord"},
+		},
+		{
+			name:        "case is not accepted by default",
+			input:       "Weather",
+			suggestions: []string{"weather"},
+		},
+		{
+			name:     "case",
+			opts:     SuggestOpts{AutoAccept: true},
+			input:    "Weather",
+			accepted: "weather",
+		},
+		{
+			name:        "prefix",
+			input:       "wea",
+			suggestions: []string{"weather"},
+		},
+		{
+			name:     "unambiguous prefix",
+			opts:     SuggestOpts{AutoAccept: true},
+			input:    "wea",
+			accepted: "weather",
+		},
+		{
+			name:        "ambiguous prefix",
+			opts:        SuggestOpts{AutoAccept: true},
+			input:       "dis",
+			suggestions: []string{"discord", "discover"},
+		},
+		{
+			name:        "short prefix",
+			opts:        SuggestOpts{AutoAccept: true},
+			input:       "we",
+			suggestions: nil,
+		},
+		{
+			name:        "prefix only",
+			opts:        SuggestOpts{MaxDistance: -1},
+			input:       "sned",
+			suggestions: nil,
+		},
+		{
+			name:        "several",
+			input:       "sear",
+			suggestions: []string{"search", "send"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accepted, suggestions := test.opts.match(test.input, candidates)
+			assert.Equal(t, test.accepted, accepted)
+			assert.Equal(t, test.suggestions, suggestions)
+		})
+	}
+}
+
+func TestUnknownCommandError(t *testing.T) {
+	err := &UnknownCommandError{Service: "discord", Name: "sned", Suggestions: []string{"send"}}
+	assert.Equal(t, `unknown command "sned" for service "discord" (did you mean /discord send?)`, err.Error())
+	assert.IsError(t, err, ErrUnknownCommand)
+}
@@ -4,9 +4,12 @@ package twicmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/twipi/twipi/proto/out/twicmdproto"
 	"github.com/twipi/twipi/proto/out/twismsproto"
@@ -19,17 +22,69 @@ type StartOpts struct {
 	// Filters is the list of message filters to apply.
 	// This parameter is optional.
 	Filters *twismsproto.MessageFilters
+	// Prompts configures asking for missing command arguments.
+	Prompts PromptOpts
+	// Sticky configures entering services.
+	Sticky StickyOpts
+}
+
+// defaultFallbackText is the text replied when no parser understands a
+// message.
+const defaultFallbackText = "Cannot understand command (no available parser)"
+
+// Pipeline is a command pipeline. Incoming messages that match its filters are
+// parsed by its parsers and dispatched to its services.
+type Pipeline struct {
+	// Name is the name of the pipeline. It is only used for logging.
+	Name string
+	// Filters selects the incoming messages that go through the pipeline.
+	// If nil, all messages are selected.
+	Filters *twismsproto.MessageFilters
+	// Parsers is the list of parsers to try in order.
+	Parsers []CommandParser
+	// Services is the lookup of services available to the pipeline.
+	Services *ServiceLookup
+	// FallbackText is the text to reply with when no parser understands the
+	// message. If empty, a default message is used.
+	FallbackText string
 }
 
 // Manager handles the command parsing and dispatching framework.
 // It is in charge of reading incoming messages from the channel and dispatching
 // them to the appropriate command parsers.
 type Manager struct {
-	SMS      twisms.MessageService
+	SMS twisms.MessageService
+	// Parsers and Services make up the default pipeline, which handles all
+	// messages that don't match any of the pipelines in Pipelines.
 	Parsers  []CommandParser
 	Services *ServiceLookup
-	Logger   *slog.Logger
-	Opts     StartOpts
+	// Pipelines is the list of additional pipelines. Each incoming message
+	// goes through the first pipeline that matches it.
+	Pipelines []Pipeline
+	// Aliases stores the aliases of each user. If nil, aliases are disabled.
+	Aliases AliasStore
+	// DefaultServices stores the default service of each user. If nil,
+	// default services are disabled.
+	DefaultServices DefaultServiceStore
+	Logger          *slog.Logger
+	Opts            StartOpts
+
+	prompts prompts
+	sticky  sticky
+}
+
+// pipeline returns the pipeline that the given message should go through.
+func (s *Manager) pipeline(msg *twismsproto.Message) *Pipeline {
+	for i := range s.Pipelines {
+		if twisms.FilterMessage(s.Pipelines[i].Filters, msg) {
+			return &s.Pipelines[i]
+		}
+	}
+	return &Pipeline{
+		Name:     "default",
+		Parsers:  s.Parsers,
+		Services: s.Services,
+	}
 }
 
 // Start starts the manager.
@@ -37,8 +92,11 @@ func (s *Manager) Start(ctx context.Context) error {
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
+	s.prompts.opts = s.Opts.Prompts
+	s.sticky.opts = s.Opts.Sticky
+
+	var queue conversationQueue
 	msgCh := make(chan *twismsproto.Message)
-	logger := s.Logger
 
 	s.SMS.SubscribeMessages(msgCh, s.Opts.Filters)
 	defer s.SMS.UnsubscribeMessages(msgCh)
@@ -59,45 +117,203 @@ func (s *Manager) Start(ctx context.Context) error {
 			}
 		}
 
-		dispatchCtx := &dispatchContext{
-			msg: msg,
-			logger: logger.With(
-				"from", msg.From,
-				"to", msg.To,
-				"timestamp", msg.Timestamp.AsTime()),
-			lookup:  s.Services,
-			msgs:    s.SMS,
-			parsers: s.Parsers,
+		dispatchCtx := s.dispatchContext(msg)
+		if !s.Opts.Prompts.Disable {
+			// Only conversations can be prompted, so this isn't done for
+			// Execute.
+			dispatchCtx.prompts = &s.prompts
+		}
+		if !s.Opts.Sticky.Disable {
+			dispatchCtx.sticky = &s.sticky
 		}
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
+		// Messages of the same conversation are handled in order, since
+		// prompts and sticky services depend on the previous message.
+		queue.run(&wg, conversationKey(msg), func() {
 			dispatchCtx.logger.Debug("dispatching message")
 			dispatchCtx.dispatch(ctx)
-		}()
+		})
+	}
+}
+
+// conversationQueue runs the functions queued for each conversation one at a
+// time and in order. The zero value is ready to use.
+type conversationQueue struct {
+	mu sync.Mutex
+	// queued holds the functions that are waiting to run. A conversation is
+	// in the map while its functions are being run.
+	queued map[string][]func()
+}
+
+// run queues fn to run after the other functions of the conversation.
+func (q *conversationQueue) run(wg *sync.WaitGroup, key string, fn func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued == nil {
+		q.queued = make(map[string][]func())
+	}
+
+	queued, running := q.queued[key]
+	q.queued[key] = append(queued, fn)
+	if running {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for fn := q.next(key); fn != nil; fn = q.next(key) {
+			fn()
+		}
+	}()
+}
+
+// next removes and returns the next function of the conversation. It returns
+// nil and forgets the conversation if there is none.
+func (q *conversationQueue) next(key string) func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued := q.queued[key]
+	if len(queued) == 0 {
+		delete(q.queued, key)
+		return nil
+	}
+
+	q.queued[key] = queued[1:]
+	return queued[0]
+}
+
+// ErrUnknownService is returned when a command names a service that does not
+// exist.
+var ErrUnknownService = errors.New("unknown service")
+
+// ErrUnknownCommand is returned when a command names a command that its
+// service does not have.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Execute parses the given message and executes the resulting command using
+// the pipeline that the message would go through. Unlike messages handled by
+// Start, the response is returned instead of being sent as a reply.
+func (s *Manager) Execute(ctx context.Context, msg *twismsproto.Message) (*twicmdproto.ExecuteResponse, error) {
+	return s.dispatchContext(msg).run(ctx)
+}
+
+// ExecuteCommand is like Execute, except the command is already parsed. The
+// message is only given to the service as context.
+func (s *Manager) ExecuteCommand(ctx context.Context, msg *twismsproto.Message, command *twicmdproto.Command) (*twicmdproto.ExecuteResponse, error) {
+	return s.dispatchContext(msg).execute(ctx, command)
+}
+
+func (s *Manager) dispatchContext(msg *twismsproto.Message) *dispatchContext {
+	pipeline := s.pipeline(msg)
+	return &dispatchContext{
+		msg: msg,
+		logger: s.Logger.With(
+			"from", msg.From,
+			"to", msg.To,
+			"timestamp", msg.Timestamp.AsTime(),
+			"pipeline", pipeline.Name),
+		lookup:   pipeline.Services,
+		msgs:     s.SMS,
+		parsers:  pipeline.Parsers,
+		fallback: pipeline.FallbackText,
+		aliases:  s.Aliases,
+		defaults: s.DefaultServices,
 	}
 }
 
 type dispatchContext struct {
-	msg     *twismsproto.Message
-	logger  *slog.Logger
-	lookup  *ServiceLookup
-	msgs    twisms.MessageSender
-	parsers []CommandParser
+	msg      *twismsproto.Message
+	logger   *slog.Logger
+	lookup   *ServiceLookup
+	msgs     twisms.MessageSender
+	parsers  []CommandParser
+	fallback string
+	// aliases is nil if aliases are disabled.
+	aliases AliasStore
+	// defaults is nil if default services are disabled.
+	defaults DefaultServiceStore
+	// sticky is nil if services can't be entered.
+	sticky *sticky
+	// prompts is nil if missing arguments shouldn't be asked for.
+	prompts *prompts
 }
 
 func (d *dispatchContext) dispatch(ctx context.Context) {
+	resp, err := d.run(ctx)
+	if err != nil {
+		d.replyText(ctx, "An error occurred while executing the command.")
+		return
+	}
+
+	switch response := resp.Response.(type) {
+	case *twicmdproto.ExecuteResponse_Text:
+		d.replyText(ctx, response.Text)
+	case *twicmdproto.ExecuteResponse_Body:
+		d.reply(ctx, response.Body)
+	case *twicmdproto.ExecuteResponse_Status:
+		// TODO: use AI to transform the status into a more human-friendly message
+		d.replyText(ctx, response.Status)
+	}
+}
+
+// run parses the message and executes the resulting command. Parsing failures
+// are returned as status responses. If the conversation has a pending prompt,
+// the message answers it instead. Aliases of the sender are expanded before
+// the message is parsed, and messages without a service name go to the entered
+// or default service of the sender.
+func (d *dispatchContext) run(ctx context.Context) (*twicmdproto.ExecuteResponse, error) {
+	body := d.msg.Body
+	text := body.GetText().GetText()
+
+	if d.prompts != nil {
+		if prompt := d.prompts.take(conversationKey(d.msg), time.Now()); prompt != nil {
+			// A new slash command cancels the prompt instead of answering it.
+			if !strings.HasPrefix(strings.TrimSpace(text), "/") {
+				return d.answerPrompt(ctx, prompt)
+			}
+			d.logger.Debug("new command cancelled the pending prompt")
+		}
+	}
+
+	resp, ok, err := d.builtin(ctx, text)
+	if err != nil {
+		d.logger.Error(
+			"failed to execute built-in command",
+			"err", err)
+		return nil, err
+	}
+	if ok {
+		return resp, nil
+	}
+
+	rewritten, err := d.rewrite(ctx, text)
+	if err != nil {
+		d.logger.Error(
+			"failed to rewrite message",
+			"err", err)
+		return nil, err
+	}
+	if rewritten != text {
+		d.logger.Debug(
+			"rewrote message",
+			"text", rewritten)
+		body = twisms.NewTextBody(rewritten)
+	}
+
 	var commandParser CommandParser
 	var command *twicmdproto.Command
-	var err error
 
 	for _, parser := range d.parsers {
-		command, err = parser.Parse(ctx, d.lookup, d.msg.Body)
+		command, err = parser.Parse(ctx, d.lookup, body)
 		if err != nil {
-			d.replyText(ctx, err.Error())
-			return
+			var missing *MissingArgumentsError
+			if d.prompts != nil && errors.As(err, &missing) {
+				return d.startPrompt(missing), nil
+			}
+			return statusResponse(err.Error()), nil
 		}
 		if command != nil {
 			commandParser = parser
@@ -106,17 +322,50 @@ func (d *dispatchContext) dispatch(ctx context.Context) {
 	}
 
 	if command == nil {
-		d.replyText(ctx, "Cannot understand command (no available parser)")
-		return
+		fallback := d.fallback
+		if fallback == "" {
+			fallback = defaultFallbackText
+		}
+		return statusResponse(fallback), nil
 	}
 
-	service, ok := d.lookup.Service(command.Service)
-	if !ok {
+	if _, ok := d.lookup.Service(command.Service); !ok && command.Service != HelpService {
 		d.logger.Error(
 			"parser returned unknown service (bug)",
 			"parser", commandParser.Name(),
 			"service", command.Service)
-		return
+		return nil, fmt.Errorf("%w %q", ErrUnknownService, command.Service)
+	}
+
+	return d.execute(ctx, command)
+}
+
+// rewrite expands the aliases of the sender in the text and adds the name of
+// the entered or default service to commands that don't name a service.
+func (d *dispatchContext) rewrite(ctx context.Context, text string) (string, error) {
+	if d.aliases != nil {
+		expanded, ok, err := d.expandAlias(ctx, text)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			text = expanded
+		}
+	}
+	return d.implicitService(ctx, text)
+}
+
+func (d *dispatchContext) execute(ctx context.Context, command *twicmdproto.Command) (*twicmdproto.ExecuteResponse, error) {
+	if command.Service == HelpService {
+		return d.help(ctx, command)
+	}
+
+	service, ok := d.lookup.Service(command.Service)
+	if !ok {
+		return nil, &UnknownServiceError{
+			Name:        command.Service,
+			Suggestions: d.lookup.suggestServices(command.Service),
+		}
 	}
 
 	d.logger.Debug(
@@ -135,18 +384,15 @@ func (d *dispatchContext) dispatch(ctx context.Context) {
 			"command", command.Command,
 			"message", d.msg.String(),
 			"err", err)
-		d.replyText(ctx, "An error occurred while executing the command.")
-		return
+		return nil, err
 	}
 
-	switch response := resp.Response.(type) {
-	case *twicmdproto.ExecuteResponse_Text:
-		d.replyText(ctx, response.Text)
-	case *twicmdproto.ExecuteResponse_Body:
-		d.reply(ctx, response.Body)
-	case *twicmdproto.ExecuteResponse_Status:
-		// TODO: use AI to transform the status into a more human-friendly message
-		d.replyText(ctx, response.Status)
+	return resp, nil
+}
+
+func statusResponse(status string) *twicmdproto.ExecuteResponse {
+	return &twicmdproto.ExecuteResponse{
+		Response: &twicmdproto.ExecuteResponse_Status{Status: status},
 	}
 }
 
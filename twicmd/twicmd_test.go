@@ -0,0 +1,38 @@
+package twicmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestConversationQueue(t *testing.T) {
+	var queue conversationQueue
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	got := map[string][]int{}
+
+	for i := range 50 {
+		for _, key := range []string{"a", "b"} {
+			queue.run(&wg, key, func() {
+				// Give later functions the chance to overtake this one.
+				time.Sleep(time.Duration(50-i) * time.Microsecond)
+
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	wg.Wait()
+
+	want := make([]int, 50)
+	for i := range want {
+		want[i] = i
+	}
+	assert.Equal(t, map[string][]int{"a": want, "b": want}, got)
+	assert.Equal(t, 0, len(queue.queued), "finished conversations are forgotten")
+}
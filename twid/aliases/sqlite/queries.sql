@@ -0,0 +1,12 @@
+-- name: Aliases :many
+SELECT * FROM aliases WHERE phone_number = ? ORDER BY name;
+
+-- name: Alias :one
+SELECT * FROM aliases WHERE phone_number = ? AND name = ?;
+
+-- name: UpsertAlias :exec
+INSERT INTO aliases (phone_number, name, expansion) VALUES (?, ?, ?)
+ON CONFLICT (phone_number, name) DO UPDATE SET expansion = excluded.expansion;
+
+-- name: DeleteAlias :execrows
+DELETE FROM aliases WHERE phone_number = ? AND name = ?;
@@ -0,0 +1,6 @@
+CREATE TABLE aliases (
+	phone_number TEXT NOT NULL,
+	name TEXT NOT NULL,
+	expansion TEXT NOT NULL,
+	PRIMARY KEY (phone_number, name)
+);
@@ -0,0 +1,106 @@
+// Package sqlite implements a SQLite storage backend for command aliases.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	_ "embed"
+
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/aliases/sqlite/queries"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+//go:embed schema.sql
+var schema string
+
+// AliasStore is the SQLite storage backend for command aliases.
+type AliasStore struct {
+	db     *sql.DB
+	q      *queries.Queries
+	logger *slog.Logger
+}
+
+var _ twicmd.AliasStore = (*AliasStore)(nil)
+
+// NewAliasStore creates a new SQLite alias store within the given storage.
+func NewAliasStore(ctx context.Context, storage *storage.Storage, logger *slog.Logger) (*AliasStore, error) {
+	db, err := storage.OpenSQLite(ctx, "aliases", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AliasStore{
+		db:     db,
+		q:      queries.New(db),
+		logger: logger,
+	}, nil
+}
+
+// Close closes the database.
+func (s *AliasStore) Close() error {
+	return s.db.Close()
+}
+
+// Aliases implements [twicmd.AliasStore].
+func (s *AliasStore) Aliases(ctx context.Context, phoneNumber string) ([]twicmd.Alias, error) {
+	rows, err := s.q.Aliases(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]twicmd.Alias, len(rows))
+	for i, row := range rows {
+		aliases[i] = twicmd.Alias{
+			Name:      row.Name,
+			Expansion: row.Expansion,
+		}
+	}
+
+	return aliases, nil
+}
+
+// Alias implements [twicmd.AliasStore].
+func (s *AliasStore) Alias(ctx context.Context, phoneNumber, name string) (twicmd.Alias, error) {
+	row, err := s.q.Alias(ctx, queries.AliasParams{
+		PhoneNumber: phoneNumber,
+		Name:        name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return twicmd.Alias{}, twicmd.ErrAliasNotFound
+		}
+		return twicmd.Alias{}, err
+	}
+	return twicmd.Alias{
+		Name:      row.Name,
+		Expansion: row.Expansion,
+	}, nil
+}
+
+// SetAlias implements [twicmd.AliasStore].
+func (s *AliasStore) SetAlias(ctx context.Context, phoneNumber string, alias twicmd.Alias) error {
+	return s.q.UpsertAlias(ctx, queries.UpsertAliasParams{
+		PhoneNumber: phoneNumber,
+		Name:        alias.Name,
+		Expansion:   alias.Expansion,
+	})
+}
+
+// DeleteAlias implements [twicmd.AliasStore].
+func (s *AliasStore) DeleteAlias(ctx context.Context, phoneNumber, name string) error {
+	n, err := s.q.DeleteAlias(ctx, queries.DeleteAliasParams{
+		PhoneNumber: phoneNumber,
+		Name:        name,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return twicmd.ErrAliasNotFound
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+func TestAliasStore(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	storage := storage.New(config.Storage{Path: t.TempDir()}, logger)
+
+	store, err := NewAliasStore(ctx, storage, logger)
+	assert.NoError(t, err)
+
+	const number = "+15555550123"
+	general := twicmd.Alias{Name: "g", Expansion: "/discord send DiscordGophers general"}
+	offtopic := twicmd.Alias{Name: "ot", Expansion: "/discord send DiscordGophers offtopic"}
+
+	assert.NoError(t, store.SetAlias(ctx, number, offtopic))
+	assert.NoError(t, store.SetAlias(ctx, number, twicmd.Alias{Name: "g", Expansion: "/weather"}))
+	assert.NoError(t, store.SetAlias(ctx, number, general))
+	assert.NoError(t, store.SetAlias(ctx, "+15555550100", twicmd.Alias{Name: "w", Expansion: "/weather"}))
+
+	aliases, err := store.Aliases(ctx, number)
+	assert.NoError(t, err)
+	assert.Equal(t, []twicmd.Alias{general, offtopic}, aliases)
+
+	alias, err := store.Alias(ctx, number, "g")
+	assert.NoError(t, err)
+	assert.Equal(t, general, alias)
+
+	_, err = store.Alias(ctx, number, "w")
+	assert.IsError(t, err, twicmd.ErrAliasNotFound)
+
+	assert.NoError(t, store.DeleteAlias(ctx, number, "g"))
+	assert.IsError(t, store.DeleteAlias(ctx, number, "g"), twicmd.ErrAliasNotFound)
+
+	aliases, err = store.Aliases(ctx, number)
+	assert.NoError(t, err)
+	assert.Equal(t, []twicmd.Alias{offtopic}, aliases)
+}
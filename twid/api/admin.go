@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/sessions"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"libdb.so/hrt"
+)
+
+func (h *handler) adminListServices(ctx context.Context, req *twidpb.AdminListServicesRequest) (*twidpb.AdminListServicesResponse, error) {
+	// Unlike listServices, a broken service doesn't fail the whole request,
+	// since admins want to see which service is broken.
+	var services []*twidpb.AdminServiceStatus
+	for _, service := range h.cmd.Services.RegisteredServices() {
+		services = append(services, h.adminServiceStatus(ctx, service))
+	}
+
+	return &twidpb.AdminListServicesResponse{
+		Services: services,
+	}, nil
+}
+
+func (h *handler) adminUpdateService(ctx context.Context, req *twidpb.AdminUpdateServiceRequest) (*twidpb.AdminServiceStatus, error) {
+	name := chi.URLParamFromCtx(ctx, "name")
+
+	var service twicmd.Service
+	for _, s := range h.cmd.Services.RegisteredServices() {
+		if s.Name() == name {
+			service = s
+			break
+		}
+	}
+	if service == nil {
+		return nil, hrt.NewHTTPError(http.StatusNotFound, "service not found")
+	}
+
+	details := map[string]string{"service": name}
+	if req.Disabled != nil {
+		h.cmd.Services.SetDisabled(name, *req.Disabled)
+		details["disabled"] = strconv.FormatBool(*req.Disabled)
+	}
+	if req.Reload {
+		reloadable, ok := service.(twicmd.ReloadableService)
+		if !ok {
+			return nil, hrt.NewHTTPError(http.StatusNotAcceptable, "service does not support reloading")
+		}
+		reloadable.Reload()
+		details["reload"] = "true"
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionServiceUpdated,
+		Admin:   true,
+		Details: details,
+	})
+
+	return h.adminServiceStatus(ctx, service), nil
+}
+
+// adminServiceStatus returns the status of the registered service.
+func (h *handler) adminServiceStatus(ctx context.Context, service twicmd.Service) *twidpb.AdminServiceStatus {
+	status := &twidpb.AdminServiceStatus{
+		Name:     service.Name(),
+		Disabled: h.cmd.Services.Disabled(service.Name()),
+	}
+	_, status.Configurable = twicmd.AsConfigurable(ctx, service)
+
+	// Disabled services can't be looked up, so resolve them directly.
+	var err error
+	if status.Disabled {
+		_, err = service.Service(ctx)
+	} else {
+		_, err = h.cmd.Services.Lookup(ctx, service.Name())
+	}
+	if err != nil {
+		status.Error = proto.String(err.Error())
+	}
+
+	return status
+}
+
+func (h *handler) adminGetControlPanel(ctx context.Context, req *twidpb.GetControlPanelRequest) (*twidpb.GetControlPanelResponse, error) {
+	return h.controlPanel(ctx, chi.URLParamFromCtx(ctx, "phone_number"))
+}
+
+func (h *handler) adminApplyControlPanel(ctx context.Context, req *twidpb.ApplyControlPanelRequest) (*twidpb.ApplyControlPanelResponse, error) {
+	return h.applyControlPanelValues(ctx, chi.URLParamFromCtx(ctx, "phone_number"), req, true)
+}
+
+func (h *authHandler) listAPIKeys(ctx context.Context, req *twidpb.ListAPIKeysRequest) (*twidpb.ListAPIKeysResponse, error) {
+	keys, err := h.store.APIKeys(ctx)
+	if err != nil {
+		h.logger.Error(
+			"failed to list API keys",
+			"err", err)
+		return nil, errInternal
+	}
+
+	resp := &twidpb.ListAPIKeysResponse{
+		ApiKeys: make([]*twidpb.APIKey, len(keys)),
+	}
+	for i, key := range keys {
+		resp.ApiKeys[i] = convertAPIKey(key)
+	}
+
+	return resp, nil
+}
+
+func (h *authHandler) createAPIKey(ctx context.Context, req *twidpb.CreateAPIKeyRequest) (*twidpb.CreateAPIKeyResponse, error) {
+	token, err := sessions.NewAPIKeyToken()
+	if err != nil {
+		h.logger.Error(
+			"failed to generate API key",
+			"err", err)
+		return nil, errInternal
+	}
+
+	key := sessions.APIKey{
+		ID:          sessions.TokenID(token),
+		Name:        req.Name,
+		PhoneNumber: req.GetPhoneNumber(),
+		CreatedAt:   time.Now(),
+	}
+	for _, scope := range req.Scopes {
+		key.Scopes = append(key.Scopes, sessions.Scope(scope))
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, hrt.WrapHTTPError(http.StatusBadRequest, err)
+	}
+
+	if err := h.store.CreateAPIKey(ctx, key); err != nil {
+		if errors.Is(err, sessions.ErrExists) {
+			return nil, hrt.NewHTTPError(http.StatusConflict, "API key already exists")
+		}
+		h.logger.Error(
+			"failed to create API key",
+			"err", err)
+		return nil, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionAPIKeyCreated,
+		Subject: key.PhoneNumber,
+		Admin:   true,
+		Details: apiKeyAuditDetails(key),
+	})
+
+	return &twidpb.CreateAPIKeyResponse{
+		ApiKey: convertAPIKey(key),
+		Token:  token,
+	}, nil
+}
+
+func (h *authHandler) deleteAPIKey(ctx context.Context, _ hrt.None) (hrt.None, error) {
+	name := chi.URLParamFromCtx(ctx, "key_name")
+
+	if err := h.store.DeleteAPIKey(ctx, name); err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return hrt.Empty, hrt.NewHTTPError(http.StatusNotFound, "API key not found")
+		}
+		h.logger.Error(
+			"failed to delete API key",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionAPIKeyRevoked,
+		Admin:   true,
+		Details: map[string]string{"name": name},
+	})
+
+	return hrt.Empty, nil
+}
+
+// apiKeyAuditDetails returns the audit event details of a created API key.
+func apiKeyAuditDetails(key sessions.APIKey) map[string]string {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+	return map[string]string{
+		"name":   key.Name,
+		"scopes": strings.Join(scopes, ","),
+	}
+}
+
+func convertAPIKey(key sessions.APIKey) *twidpb.APIKey {
+	pb := &twidpb.APIKey{
+		Name:      key.Name,
+		Scopes:    make([]string, len(key.Scopes)),
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	for i, scope := range key.Scopes {
+		pb.Scopes[i] = string(scope)
+	}
+	if key.PhoneNumber != "" {
+		pb.PhoneNumber = proto.String(key.PhoneNumber)
+	}
+	if !key.LastUsedAt.IsZero() {
+		pb.LastUsedAt = timestamppb.New(key.LastUsedAt)
+	}
+	return pb
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twicmd"
+	"libdb.so/hrt"
+)
+
+var errAliasNotFound = hrt.NewHTTPError(http.StatusNotFound, "alias not found")
+
+func (h *handler) listAliases(ctx context.Context, req *twidpb.ListAliasesRequest) (*twidpb.ListAliasesResponse, error) {
+	session := sessionFromContext(ctx)
+
+	aliases, err := h.cmd.Aliases.Aliases(ctx, session.PhoneNumber)
+	if err != nil {
+		h.logger.Error(
+			"failed to list aliases",
+			"err", err)
+		return nil, errInternal
+	}
+
+	resp := &twidpb.ListAliasesResponse{
+		Aliases: make([]*twidpb.Alias, len(aliases)),
+	}
+	for i, alias := range aliases {
+		resp.Aliases[i] = &twidpb.Alias{
+			Name:      alias.Name,
+			Expansion: alias.Expansion,
+		}
+	}
+
+	return resp, nil
+}
+
+func (h *handler) setAlias(ctx context.Context, req *twidpb.SetAliasRequest) (*twidpb.Alias, error) {
+	session := sessionFromContext(ctx)
+
+	alias, err := h.cmd.SetAlias(ctx, session.PhoneNumber, twicmd.Alias{
+		Name:      chi.URLParamFromCtx(ctx, "name"),
+		Expansion: req.Expansion,
+	})
+	if err != nil {
+		if errors.Is(err, twicmd.ErrInvalidAlias) {
+			return nil, hrt.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		h.logger.Error(
+			"failed to set alias",
+			"err", err)
+		return nil, errInternal
+	}
+
+	return &twidpb.Alias{
+		Name:      alias.Name,
+		Expansion: alias.Expansion,
+	}, nil
+}
+
+func (h *handler) deleteAlias(ctx context.Context, _ hrt.None) (hrt.None, error) {
+	session := sessionFromContext(ctx)
+	name := strings.ToLower(chi.URLParamFromCtx(ctx, "name"))
+
+	if err := h.cmd.Aliases.DeleteAlias(ctx, session.PhoneNumber, name); err != nil {
+		if errors.Is(err, twicmd.ErrAliasNotFound) {
+			return hrt.Empty, errAliasNotFound
+		}
+		h.logger.Error(
+			"failed to delete alias",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	return hrt.Empty, nil
+}
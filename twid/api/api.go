@@ -3,15 +3,22 @@ package api
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
+	"github.com/twipi/twipi/internal/openapi"
 	"github.com/twipi/twipi/internal/srvutil"
 	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
 	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/proto/out/twismsproto"
 	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/sessions"
 	"github.com/twipi/twipi/twisms"
 	"libdb.so/ctxt"
 	"libdb.so/hrt"
@@ -35,44 +42,219 @@ func writeError(w http.ResponseWriter, err error) {
 	hrtOpts.ErrorWriter.WriteError(w, err)
 }
 
+var openapiConfig = openapi.Config{
+	Title:          "twid API",
+	Description:    "The API of the twid daemon. Requests and responses are Protobuf messages encoded as JSON.",
+	Version:        "v0",
+	QueryParameter: "params",
+	SecuritySchemes: map[string]*openapi.SecurityScheme{
+		"bearer": {
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: "A session token from logging in, or an API key.",
+		},
+	},
+}
+
+// newOpenAPIConfig returns the OpenAPI configuration, which documents the
+// cookie security scheme if cookie sessions are enabled.
+func newOpenAPIConfig(cookies cookieSessions) openapi.Config {
+	cfg := openapiConfig
+	if cookies.enabled() {
+		cfg.SecuritySchemes = maps.Clone(cfg.SecuritySchemes)
+		cfg.SecuritySchemes["cookie"] = &openapi.SecurityScheme{
+			Type: "apiKey",
+			In:   "cookie",
+			Name: cookies.cfg.Name,
+			Description: "A session cookie, set by logging in with cookie set to true. " +
+				"Requests other than GET must carry the CSRF token in the " + csrfHeader + " header.",
+		}
+	}
+	return cfg
+}
+
+// Backend contains everything that the API is built on.
+type Backend struct {
+	SMS           twisms.MessageSender
+	Commands      *twicmd.Manager
+	Sessions      sessions.Store
+	Conversations ConversationLog
+	Audit         audit.Log
+	// LoginSMS sends login codes. Unlike SMS, it must not record the messages
+	// that it sends in the conversation log, since they are secrets.
+	LoginSMS twisms.MessageSender
+}
+
+// ConversationLog provides the history and a live stream of all incoming and
+// outgoing messages.
+type ConversationLog interface {
+	twisms.MessageSubscriber
+	// RecentMessages returns the messages sent from or to the given phone
+	// number since the given time, oldest first.
+	RecentMessages(ctx context.Context, phoneNumber string, since time.Time) ([]*twismsproto.Message, error)
+}
+
 // New returns an HTTP handler that serves the main API.
-func New(sms twisms.MessageSender, cmd *twicmd.Manager, logger *slog.Logger) http.Handler {
-	h := &handler{
-		sms:    sms,
-		cmd:    cmd,
-		auth:   newAuthHandler(sms, logger),
+func New(cfg config.API, backend Backend, logger *slog.Logger) (http.Handler, error) {
+	auditor := auditor{
+		log:    backend.Audit,
 		logger: logger,
 	}
 
+	auth, err := newAuthHandler(cfg, backend.LoginSMS, backend.Sessions, auditor, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &handler{
+		sms:           backend.SMS,
+		cmd:           backend.Commands,
+		conversations: backend.Conversations,
+		auth:          auth,
+		audit:         auditor,
+		logger:        logger,
+	}
+
+	realIP, err := realIPMiddleware(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	cors, err := corsMiddleware(cfg.CORS)
+	if err != nil {
+		return nil, err
+	}
+
 	r := chi.NewMux()
-	r.Use(cors.AllowAll().Handler)
+	if realIP != nil {
+		r.Use(realIP)
+	}
+	if cors != nil {
+		r.Use(cors)
+	}
+	r.Use(securityHeaders(cfg.SecurityHeaders))
 	r.Use(middleware.CleanPath)
 	r.Use(hrt.Use(hrtOpts))
+	r.Use(withResponseWriter)
 
 	r.Route("/login", func(r chi.Router) {
-		r.Post("/phase1", hrt.Wrap(h.auth.loginPhase1))
-		r.Post("/phase2", hrt.Wrap(h.auth.loginPhase2))
+		r.Method(http.MethodPost, "/phase1", openapi.Wrap(h.auth.loginPhase1, "Send a login code to a phone number"))
+		r.Method(http.MethodPost, "/phase2", openapi.Wrap(h.auth.loginPhase2, "Log in using a login code"))
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.auth.authMiddleware)
+		r.Use(requireSession)
+		r.Method(http.MethodPost, "/logout", openapi.Wrap(h.auth.logout, "Log out of the current session"))
+		r.Method(http.MethodPost, "/execute", openapi.Wrap(h.executeCommand, "Execute a command"))
+		r.Method(http.MethodPost, "/autocomplete", openapi.Wrap(h.autocomplete, "Suggest values for a command argument"))
+
+		r.Route("/sessions", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.auth.listSessions, "List the caller's sessions"))
+			r.Method(http.MethodPost, "/revoke_others", openapi.Wrap(h.auth.revokeOtherSessions, "Revoke all other sessions of the caller"))
+			r.Method(http.MethodDelete, "/{id}", openapi.Wrap(h.auth.revokeSession, "Revoke a session of the caller"))
+		})
+
+		r.Route("/aliases", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.listAliases, "List the caller's command aliases"))
+			r.Method(http.MethodPut, "/{name}", openapi.Wrap(h.setAlias, "Add or replace a command alias of the caller"))
+			r.Method(http.MethodDelete, "/{name}", openapi.Wrap(h.deleteAlias, "Delete a command alias of the caller"))
+		})
+
+		r.Route("/totp", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.auth.getTOTP, "Get the caller's TOTP status"))
+			r.Method(http.MethodPost, "/enroll", openapi.Wrap(h.auth.enrollTOTP, "Start enrolling the caller in TOTP"))
+			r.Method(http.MethodPost, "/confirm", openapi.Wrap(h.auth.confirmTOTP, "Enable TOTP using a code from the new authenticator"))
+			r.Method(http.MethodPost, "/recovery_codes", openapi.Wrap(h.auth.regenerateRecoveryCodes, "Replace the caller's recovery codes"))
+			r.Method(http.MethodPost, "/disable", openapi.Wrap(h.auth.disableTOTP, "Disable TOTP for the caller"))
+		})
+	})
+
+	// Browsers can't set headers on EventSource requests, so they should log
+	// in using a cookie session to stream the conversation.
+	r.With(h.auth.authMiddleware, requireSession).
+		Method(http.MethodGet, "/conversation", &openapi.Endpoint{
+			Handler:  http.HandlerFunc(h.streamConversation),
+			Summary:  "Stream the caller's conversation with twid",
+			Response: (*twismsproto.Message)(nil).ProtoReflect().Descriptor(),
+			Stream:   true,
+		})
+
+	r.With(h.auth.authMiddleware, requireScope(sessions.ScopeSendMessages)).
+		Method(http.MethodPost, "/messages", openapi.Wrap(h.sendMessage, "Send a message"))
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(h.auth.authMiddleware)
+		r.Use(requireRole(roleAdmin))
+
+		r.Method(http.MethodGet, "/services", openapi.Wrap(h.adminListServices, "List all services and their status"))
+		r.Method(http.MethodPatch, "/services/{name}", openapi.Wrap(h.adminUpdateService, "Disable, enable or reload a service"))
+		r.Route("/users/{phone_number}/services/{name}/cp", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.adminGetControlPanel, "Get the control panel of a service for any user"))
+			r.Method(http.MethodPatch, "/", openapi.Wrap(h.adminApplyControlPanel, "Change the control panel values of a service for any user"))
+		})
+
+		r.Method(http.MethodGet, "/audit", openapi.Wrap(h.adminListAuditEvents, "Query the audit log"))
+
+		r.Route("/apikeys", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.auth.listAPIKeys, "List all API keys"))
+			r.Method(http.MethodPost, "/", openapi.Wrap(h.auth.createAPIKey, "Create an API key"))
+			r.Method(http.MethodDelete, "/{key_name}", openapi.Wrap(h.auth.deleteAPIKey, "Revoke an API key"))
+		})
 	})
 
-	r.Get("/", hrt.Wrap(h.listServices))
+	r.Method(http.MethodGet, "/openapi.json", openapi.Serve(r, newOpenAPIConfig(auth.cookies)))
+
+	// Services are kept under their own prefix, so that their names can't
+	// shadow the other routes.
+	r.Route("/services", func(r chi.Router) {
+		r.Method(http.MethodGet, "/", openapi.Wrap(h.listServices, "List all services"))
 
-	r.Route("/{name}", func(r chi.Router) {
-		r.Get("/", hrt.Wrap(h.getService))
+		r.Route("/{name}", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", openapi.Wrap(h.getService, "Describe a service"))
 
-		r.Route("/cp", func(r chi.Router) {
-			r.Use(h.auth.sessionMiddleware)
-			r.Get("/", hrt.Wrap(h.getControlPanel))
+			r.Route("/cp", func(r chi.Router) {
+				r.Use(h.auth.authMiddleware)
+				r.With(requireScope(sessions.ScopeReadServices)).
+					Method(http.MethodGet, "/", openapi.Wrap(h.getControlPanel, "Get the caller's control panel of a service"))
+				r.With(requireScope(sessions.ScopeManageConfig)).
+					Method(http.MethodPatch, "/", openapi.Wrap(h.applyControlPanel, "Change the caller's control panel values of a service"))
+			})
 		})
 	})
 
-	return r
+	return r, nil
 }
 
 type handler struct {
-	sms    twisms.MessageSender
-	cmd    *twicmd.Manager
-	auth   *authHandler
-	logger *slog.Logger
+	sms           twisms.MessageSender
+	cmd           *twicmd.Manager
+	conversations ConversationLog
+	auth          *authHandler
+	audit         auditor
+	logger        *slog.Logger
+}
+
+func (h *handler) sendMessage(ctx context.Context, req *twidpb.SendMessageRequest) (hrt.None, error) {
+	caller, _ := ctxt.From[authCaller](ctx)
+
+	if number := caller.PhoneNumber(); number != "" && number != req.To {
+		return hrt.Empty, hrt.NewHTTPError(http.StatusForbidden, "API key may only send messages to its own phone number")
+	}
+
+	if req.To == "" || req.Body == nil {
+		return hrt.Empty, hrt.NewHTTPError(http.StatusBadRequest, "missing recipient or body")
+	}
+
+	if err := twisms.SendAutoTextMessage(ctx, h.sms, req.To, req.Body); err != nil {
+		h.logger.Error(
+			"failed to send message",
+			"to", req.To,
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	return hrt.Empty, nil
 }
 
 func (h *handler) listServices(ctx context.Context, req *twidpb.ListServicesRequest) (*twidpb.ListServicesResponse, error) {
@@ -122,28 +304,144 @@ func (h *handler) getService(ctx context.Context, req *twidpb.GetServiceRequest)
 }
 
 func (h *handler) getControlPanel(ctx context.Context, req *twidpb.GetControlPanelRequest) (*twidpb.GetControlPanelResponse, error) {
-	serviceName := chi.URLParamFromCtx(ctx, "name")
-	session, _ := ctxt.From[authSession](ctx)
+	caller, _ := ctxt.From[authCaller](ctx)
+	return h.controlPanel(ctx, caller.PhoneNumber())
+}
 
-	service, err := h.cmd.Services.Lookup(ctx, serviceName)
+// controlPanel returns the control panel of the given phone number. Values of
+// sensitive options are left out if the caller may not see them.
+func (h *handler) controlPanel(ctx context.Context, phoneNumber string) (*twidpb.GetControlPanelResponse, error) {
+	service, cp, err := h.lookupControlPanel(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	cp, ok := service.Service.(twicmd.ConfigurableService)
-	if !ok {
-		return nil, hrt.NewHTTPError(http.StatusNotAcceptable, "service does not support control panel")
-	}
-
 	options, err := cp.ConfigurationValues(ctx, &twicmdcfgpb.OptionsRequest{
-		PhoneNumber: session.PhoneNumber,
+		PhoneNumber: phoneNumber,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &twidpb.GetControlPanelResponse{
+	resp := &twidpb.GetControlPanelResponse{
 		Service: service.Description,
 		Values:  options.Values,
+	}
+
+	caller, _ := ctxt.From[authCaller](ctx)
+	sensitive := sensitiveOptions(service.Description.GetOptionsSchema())
+	if len(sensitive) > 0 && !h.auth.mayAccessSensitive(caller) {
+		resp.SensitiveHidden = true
+		resp.Values = slices.DeleteFunc(resp.Values, func(value *twicmdcfgpb.OptionValue) bool {
+			return sensitive[value.Id]
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *handler) applyControlPanel(ctx context.Context, req *twidpb.ApplyControlPanelRequest) (*twidpb.ApplyControlPanelResponse, error) {
+	// Users may only ever change their own settings, so the phone number
+	// always comes from the caller.
+	caller, _ := ctxt.From[authCaller](ctx)
+	return h.applyControlPanelValues(ctx, caller.PhoneNumber(), req, false)
+}
+
+// applyControlPanelValues applies the values to the control panel of the
+// given phone number. admin is true if an admin is changing someone else's
+// values.
+func (h *handler) applyControlPanelValues(ctx context.Context, phoneNumber string, req *twidpb.ApplyControlPanelRequest, admin bool) (*twidpb.ApplyControlPanelResponse, error) {
+	service, cp, err := h.lookupControlPanel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, _ := ctxt.From[authCaller](ctx)
+	if !h.auth.mayAccessSensitive(caller) {
+		sensitive := sensitiveOptions(service.Description.GetOptionsSchema())
+		for _, value := range req.Values {
+			if sensitive[value.Id] {
+				return nil, errSecondFactorNeeded
+			}
+		}
+	}
+
+	// The old values are only needed for the audit log, so failing to get
+	// them must not stop the values from being applied.
+	var oldValues []*twicmdcfgpb.OptionValue
+	old, err := cp.ConfigurationValues(ctx, &twicmdcfgpb.OptionsRequest{
+		PhoneNumber: phoneNumber,
+	})
+	if err != nil {
+		h.logger.Warn(
+			"failed to get old configuration values for the audit log",
+			"service", service.Description.Name,
+			"err", err)
+	} else {
+		oldValues = old.Values
+	}
+
+	resp, err := cp.ApplyConfigurationValues(ctx, &twicmdcfgpb.ApplyRequest{
+		PhoneNumber: phoneNumber,
+		Values:      req.Values,
+	})
+
+	details := auditResult(err)
+	details["service"] = service.Description.Name
+	if err == nil && !resp.Success {
+		details["result"] = "rejected"
+	}
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionConfigApplied,
+		Subject: phoneNumber,
+		Admin:   admin,
+		Details: details,
+		Changes: configChanges(service.Description.GetOptionsSchema(), oldValues, req.Values),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &twidpb.ApplyControlPanelResponse{
+		Success: resp.Success,
+		Errors:  resp.Errors,
 	}, nil
 }
+
+// sensitiveOptions returns the IDs of the sensitive options in the schema.
+func sensitiveOptions(schema *twicmdcfgpb.Schema) map[string]bool {
+	options := schema.GetOptions()
+	for _, category := range schema.GetCategories() {
+		options = append(slices.Clip(options), category.Options...)
+	}
+
+	sensitive := make(map[string]bool)
+	for _, option := range options {
+		if option.GetString_().GetSensitive() {
+			sensitive[option.Id] = true
+		}
+	}
+	return sensitive
+}
+
+// lookupControlPanel looks up the service named in the URL and returns it
+// along with its control panel.
+func (h *handler) lookupControlPanel(ctx context.Context) (*twicmd.ResolvedService, twicmd.ConfigurableService, error) {
+	serviceName := chi.URLParamFromCtx(ctx, "name")
+
+	service, err := h.cmd.Services.Lookup(ctx, serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if service == nil {
+		return nil, nil, hrt.NewHTTPError(http.StatusNotFound, "service not found")
+	}
+
+	cp, ok := twicmd.AsConfigurable(ctx, service.Service)
+	if !ok {
+		return nil, nil, hrt.NewHTTPError(http.StatusNotAcceptable, "service does not support control panel")
+	}
+
+	return service, cp, nil
+}
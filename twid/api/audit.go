@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/twipi/twipi/proto/out/twicmdcfgpb"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twid/audit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"libdb.so/ctxt"
+	"libdb.so/hrt"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// auditor records audit events for API requests.
+type auditor struct {
+	log    audit.Log
+	logger *slog.Logger
+}
+
+// record records the event, filling in the actor and IP address from the
+// request. Failing to record an event is logged but never fails the request.
+func (a auditor) record(ctx context.Context, event audit.Event) {
+	if event.Actor == "" {
+		caller, _ := ctxt.From[authCaller](ctx)
+		event.Actor = caller.actor()
+	}
+	if r := hrt.RequestFromContext(ctx); r != nil {
+		event.IP = remoteIP(r)
+	}
+
+	if err := a.log.Record(ctx, event); err != nil {
+		a.logger.Error(
+			"failed to record audit event",
+			"action", event.Action,
+			"err", err)
+	}
+}
+
+// auditResult returns the details of an event that describe the outcome of
+// the action.
+func auditResult(err error) map[string]string {
+	if err != nil {
+		return map[string]string{"result": "error", "error": err.Error()}
+	}
+	return map[string]string{"result": "ok"}
+}
+
+// configChanges returns the changes made by applying values over old.
+// Sensitive string options are masked.
+func configChanges(schema *twicmdcfgpb.Schema, old, values []*twicmdcfgpb.OptionValue) []audit.Change {
+	sensitive := sensitiveOptions(schema)
+
+	oldValues := make(map[string]*twicmdcfgpb.OptionValue, len(old))
+	for _, value := range old {
+		oldValues[value.Id] = value
+	}
+
+	changes := make([]audit.Change, len(values))
+	for i, value := range values {
+		change := audit.Change{
+			Key: value.Id,
+			Old: formatOptionValue(oldValues[value.Id]),
+			New: formatOptionValue(value),
+		}
+		if sensitive[value.Id] {
+			// Still show whether the value was set.
+			if change.Old != "" {
+				change.Old = audit.MaskedValue
+			}
+			if change.New != "" {
+				change.New = audit.MaskedValue
+			}
+		}
+		changes[i] = change
+	}
+
+	return changes
+}
+
+func formatOptionValue(value *twicmdcfgpb.OptionValue) string {
+	switch value := value.GetValue().(type) {
+	case *twicmdcfgpb.OptionValue_String_:
+		return value.String_
+	case *twicmdcfgpb.OptionValue_StringList:
+		b, _ := json.Marshal(value.StringList.GetValues())
+		return string(b)
+	case *twicmdcfgpb.OptionValue_Int:
+		return strconv.FormatInt(value.Int, 10)
+	case *twicmdcfgpb.OptionValue_Switch:
+		return strconv.FormatBool(value.Switch)
+	default:
+		return ""
+	}
+}
+
+func (h *handler) adminListAuditEvents(ctx context.Context, req *twidpb.ListAuditEventsRequest) (*twidpb.ListAuditEventsResponse, error) {
+	query := audit.Query{
+		Action:  audit.Action(req.Action),
+		Actor:   req.Actor,
+		Subject: req.Subject,
+		Admin:   req.Admin,
+		Limit:   int(req.PageSize),
+	}
+	if req.Since != nil {
+		query.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		query.Until = req.Until.AsTime()
+	}
+	if req.PageToken != "" {
+		id, err := strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, hrt.NewHTTPError(http.StatusBadRequest, "invalid page token")
+		}
+		query.BeforeID = id
+	}
+
+	switch {
+	case query.Limit <= 0:
+		query.Limit = defaultAuditPageSize
+	case query.Limit > maxAuditPageSize:
+		query.Limit = maxAuditPageSize
+	}
+
+	// Fetch one more event to know whether there is a next page.
+	query.Limit++
+	events, err := h.audit.log.Events(ctx, query)
+	if err != nil {
+		h.logger.Error(
+			"failed to query audit log",
+			"err", err)
+		return nil, errInternal
+	}
+
+	resp := &twidpb.ListAuditEventsResponse{}
+	if len(events) == query.Limit {
+		events = events[:len(events)-1]
+		resp.NextPageToken = strconv.FormatInt(events[len(events)-1].ID, 10)
+	}
+
+	resp.Events = make([]*twidpb.AuditEvent, len(events))
+	for i, event := range events {
+		pb := &twidpb.AuditEvent{
+			Id:      event.ID,
+			Time:    timestamppb.New(event.Time),
+			Action:  string(event.Action),
+			Actor:   event.Actor,
+			Subject: event.Subject,
+			Ip:      event.IP,
+			Admin:   event.Admin,
+			Details: event.Details,
+			Changes: make([]*twidpb.AuditChange, len(event.Changes)),
+		}
+		for j, change := range event.Changes {
+			pb.Changes[j] = &twidpb.AuditChange{
+				Key: change.Key,
+				Old: change.Old,
+				New: change.New,
+			}
+		}
+		resp.Events[i] = pb
+	}
+
+	return resp, nil
+}
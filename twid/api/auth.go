@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,8 +15,12 @@ import (
 
 	mathrand "math/rand/v2"
 
-	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/twipi/cfgutil"
+	"github.com/twipi/twipi/internal/openapi"
 	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/sessions"
 	"github.com/twipi/twipi/twisms"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"libdb.so/ctxt"
@@ -26,51 +32,286 @@ const (
 	sessionExpiration   = 5 * 24 * time.Hour
 )
 
-var errInvalidLogin = hrt.WrapHTTPError(401, fmt.Errorf("invalid login or session"))
+// defaultLoginConfig contains the defaults for zero values in [config.Login].
+var defaultLoginConfig = config.Login{
+	IPRateLimit: config.RateLimit{
+		Count:  10,
+		Period: cfgutil.Duration(time.Hour),
+	},
+	NumberRateLimit: config.RateLimit{
+		Count:  5,
+		Period: cfgutil.Duration(time.Hour),
+	},
+	Cooldown:            cfgutil.Duration(time.Minute),
+	MaxOutstandingCodes: 3,
+	MaxAttempts:         5,
+}
+
+var (
+	errInvalidLogin    = hrt.WrapHTTPError(401, fmt.Errorf("invalid login or session"))
+	errTooManyRequests = hrt.NewHTTPError(http.StatusTooManyRequests, "too many login attempts, try again later")
+	errLoginNotAllowed = hrt.NewHTTPError(http.StatusForbidden, "phone number is not allowed to log in")
+)
 
 type authHandler struct {
-	sms      twisms.MessageSender
-	logger   *slog.Logger
-	codes    *xsync.MapOf[loginCode, authSession]
-	sessions *xsync.MapOf[string, authSession]
+	sms     twisms.MessageSender
+	store   sessions.Store
+	cfg     config.Login
+	admins  []string
+	cookies cookieSessions
+	audit   auditor
+	logger  *slog.Logger
+
+	ipLimiter       *rateLimiter
+	numberLimiter   *rateLimiter
+	cooldownLimiter *rateLimiter
+	totpLimiter     *rateLimiter
 }
 
-func newAuthHandler(sms twisms.MessageSender, logger *slog.Logger) *authHandler {
+func newAuthHandler(apiCfg config.API, sms twisms.MessageSender, store sessions.Store, audit auditor, logger *slog.Logger) (*authHandler, error) {
+	cfg := apiCfg.Login
+
+	if cfg.IPRateLimit.Count == 0 {
+		cfg.IPRateLimit = defaultLoginConfig.IPRateLimit
+	}
+	if cfg.NumberRateLimit.Count == 0 {
+		cfg.NumberRateLimit = defaultLoginConfig.NumberRateLimit
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = defaultLoginConfig.Cooldown
+	}
+	if cfg.MaxOutstandingCodes == 0 {
+		cfg.MaxOutstandingCodes = defaultLoginConfig.MaxOutstandingCodes
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaultLoginConfig.MaxAttempts
+	}
+
+	for _, country := range cfg.Countries {
+		if _, ok := twisms.CountryCallingCode(country); !ok {
+			return nil, fmt.Errorf("unknown login country %q", country)
+		}
+	}
+
+	cookies, err := newCookieSessions(apiCfg.CookieSessions)
+	if err != nil {
+		return nil, err
+	}
+
 	return &authHandler{
-		sms:      sms,
-		logger:   logger,
-		codes:    xsync.NewMapOf[loginCode, authSession](),
-		sessions: xsync.NewMapOf[string, authSession](),
+		sms:     sms,
+		store:   store,
+		cfg:     cfg,
+		admins:  apiCfg.Admins,
+		cookies: cookies,
+		audit:   audit,
+		logger:  logger,
+
+		ipLimiter: newRateLimiter(
+			cfg.IPRateLimit.Count,
+			cfg.IPRateLimit.Period.AsDuration()),
+		numberLimiter: newRateLimiter(
+			cfg.NumberRateLimit.Count,
+			cfg.NumberRateLimit.Period.AsDuration()),
+		// Used codes are deleted, so the cooldown can't be derived from the
+		// outstanding codes.
+		cooldownLimiter: newRateLimiter(1, cfg.Cooldown.AsDuration()),
+		totpLimiter:     newRateLimiter(totpAttempts, totpAttemptPeriod),
+	}, nil
+}
+
+// authCaller is the authenticated caller of a request. Exactly one of Session
+// and APIKey is set.
+type authCaller struct {
+	Session *sessions.Session
+	APIKey  *sessions.APIKey
+	Role    role
+}
+
+// PhoneNumber returns the phone number that the caller acts on behalf of. It
+// is empty for API keys that aren't bound to a phone number.
+func (c authCaller) PhoneNumber() string {
+	if c.APIKey != nil {
+		return c.APIKey.PhoneNumber
 	}
+	return c.Session.PhoneNumber
 }
 
-func (h *authHandler) sessionMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if !strings.HasPrefix(token, "Bearer ") {
-			writeError(w, errInvalidLogin)
-			return
+// HasScope returns true if the caller may do what the scope allows. Sessions
+// may only read and manage their own configuration.
+func (c authCaller) HasScope(scope sessions.Scope) bool {
+	if c.APIKey != nil {
+		return c.APIKey.HasScope(scope)
+	}
+	return scope == sessions.ScopeReadServices || scope == sessions.ScopeManageConfig
+}
+
+// actor returns the caller as an [audit.Event] actor. It is empty if there is
+// no caller.
+func (c authCaller) actor() string {
+	switch {
+	case c.APIKey != nil:
+		return "apikey:" + c.APIKey.Name
+	case c.Session != nil:
+		return c.Session.PhoneNumber
+	default:
+		return ""
+	}
+}
+
+// authMiddleware authenticates the request using either a session token or an
+// API key token and stores the [authCaller] in the request context. If cookie
+// sessions are enabled, requests without an Authorization header may use the
+// session cookie instead.
+func (h *authHandler) authMiddleware(next http.Handler) http.Handler {
+	schemes := []string{"bearer"}
+	if h.cookies.enabled() {
+		schemes = append(schemes, "cookie")
+	}
+
+	return openapi.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		var fromCookie bool
+		if header := r.Header.Get("Authorization"); header != "" {
+			if !strings.HasPrefix(header, "Bearer ") {
+				writeError(w, errInvalidLogin)
+				return
+			}
+			token = strings.TrimPrefix(header, "Bearer ")
+		} else {
+			token = h.cookies.sessionToken(r)
+			fromCookie = true
 		}
-		token = strings.TrimPrefix(token, "Bearer ")
 
-		session, ok := h.sessions.Load(token)
-		if !ok {
+		if token == "" || (fromCookie && sessions.IsAPIKeyToken(token)) {
 			writeError(w, errInvalidLogin)
 			return
 		}
 
-		if session.Expired() {
-			h.sessions.Delete(token)
+		if fromCookie {
+			if err := checkCSRF(r, token); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
 
-			writeError(w, errInvalidLogin)
+		var caller authCaller
+		var err error
+		if sessions.IsAPIKeyToken(token) {
+			caller.APIKey, err = h.authenticateAPIKey(r.Context(), token)
+		} else {
+			caller.Session, err = h.authenticateSession(r.Context(), token)
+		}
+		if err != nil {
+			if fromCookie && errors.Is(err, errInvalidLogin) {
+				h.cookies.clearSession(w)
+			}
+			writeError(w, err)
 			return
 		}
+		caller.Role = h.roleOf(caller)
 
-		session.ExpiresAt = time.Now().Add(sessionExpiration).Unix()
-		h.sessions.Store(token, session)
+		if fromCookie {
+			// Sessions are extended on every use, so the cookie is too.
+			h.cookies.setSession(w, token, caller.Session.ExpiresAt)
+		}
 
-		ctx := ctxt.With(r.Context(), session)
+		ctx := ctxt.With(r.Context(), caller)
 		next.ServeHTTP(w, r.WithContext(ctx))
+	}), openapi.Requirement{Schemes: schemes})
+}
+
+func (h *authHandler) authenticateSession(ctx context.Context, token string) (*sessions.Session, error) {
+	session, err := h.store.Session(ctx, sessions.TokenID(token))
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return nil, errInvalidLogin
+		}
+		h.logger.Error(
+			"failed to load session",
+			"err", err)
+		return nil, errInternal
+	}
+
+	// The allowlist may have changed since the session was created.
+	if !h.allowedNumber(session.PhoneNumber) {
+		return nil, errLoginNotAllowed
+	}
+
+	now := time.Now()
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(sessionExpiration)
+
+	if err := h.store.TouchSession(ctx, session.ID, session.LastUsedAt, session.ExpiresAt); err != nil {
+		h.logger.Error(
+			"failed to update session",
+			"err", err)
+		return nil, errInternal
+	}
+
+	return &session, nil
+}
+
+func (h *authHandler) authenticateAPIKey(ctx context.Context, token string) (*sessions.APIKey, error) {
+	key, err := h.store.APIKey(ctx, sessions.TokenID(token))
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return nil, errInvalidLogin
+		}
+		h.logger.Error(
+			"failed to load API key",
+			"err", err)
+		return nil, errInternal
+	}
+
+	// Keys bound to a phone number act as it, so they follow the same
+	// allowlist and denylist as its sessions.
+	if key.PhoneNumber != "" && !h.allowedNumber(key.PhoneNumber) {
+		return nil, errLoginNotAllowed
+	}
+
+	key.LastUsedAt = time.Now()
+
+	if err := h.store.TouchAPIKey(ctx, key.ID, key.LastUsedAt); err != nil {
+		h.logger.Error(
+			"failed to update API key",
+			"err", err)
+		return nil, errInternal
+	}
+
+	return &key, nil
+}
+
+// requireScope returns a middleware that only allows callers with the given
+// scope. It must be used after authMiddleware.
+func requireScope(scope sessions.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return openapi.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, _ := ctxt.From[authCaller](r.Context())
+			if !caller.HasScope(scope) {
+				writeError(w, hrt.NewHTTPError(http.StatusForbidden, fmt.Sprintf("missing scope %q", scope)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}), openapi.Requirement{
+			Roles:       []string{string(scope)},
+			Description: fmt.Sprintf("Requires the %s scope.", scope),
+		})
+	}
+}
+
+// requireSession is a middleware that only allows callers that logged in
+// using a session. It must be used after authMiddleware.
+func requireSession(next http.Handler) http.Handler {
+	return openapi.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := ctxt.From[authCaller](r.Context())
+		if caller.Session == nil {
+			writeError(w, hrt.NewHTTPError(http.StatusForbidden, "a login session is required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), openapi.Requirement{
+		Description: "Requires a login session; API keys are not accepted.",
 	})
 }
 
@@ -85,10 +326,81 @@ var verificationMessage = strings.TrimSpace(strings.NewReplacer(
 	"\r", "",
 ).Replace(verificationMessage_))
 
-func (h *authHandler) loginPhase1(ctx context.Context, req *twidpb.LoginPhase1Request) (hrt.None, error) {
-	code, err := generateLoginCode(h.codes, authSession{
+// allowedCountry returns true if the phone number belongs to one of the
+// countries that are allowed to log in.
+func (h *authHandler) allowedCountry(phoneNumber string) bool {
+	if len(h.cfg.Countries) == 0 {
+		return true
+	}
+	for _, country := range h.cfg.Countries {
+		if twisms.PhoneNumberInCountry(phoneNumber, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLoginLimits returns an error if a new code may not be sent to the phone
+// number right now.
+func (h *authHandler) checkLoginLimits(ctx context.Context, phoneNumber string, now time.Time) error {
+	if !h.allowedNumber(phoneNumber) || !h.allowedCountry(phoneNumber) {
+		return errLoginNotAllowed
+	}
+
+	if r := hrt.RequestFromContext(ctx); r != nil {
+		if !h.ipLimiter.allow(remoteIP(r), now) {
+			h.logger.Warn(
+				"phase 1: IP address is rate limited",
+				"ip", remoteIP(r))
+			return errTooManyRequests
+		}
+	}
+
+	codes, err := h.store.Codes(ctx, phoneNumber)
+	if err != nil {
+		h.logger.Error(
+			"failed to load outstanding auth codes",
+			"err", err)
+		return errInternal
+	}
+
+	if len(codes) >= h.cfg.MaxOutstandingCodes {
+		return errTooManyRequests
+	}
+
+	if !h.cooldownLimiter.allow(phoneNumber, now) {
+		return errTooManyRequests
+	}
+
+	if !h.numberLimiter.allow(phoneNumber, now) {
+		h.logger.Warn(
+			"phase 1: phone number is rate limited",
+			"phone_number", phoneNumber)
+		return errTooManyRequests
+	}
+
+	return nil
+}
+
+func (h *authHandler) loginPhase1(ctx context.Context, req *twidpb.LoginPhase1Request) (_ hrt.None, err error) {
+	defer func() {
+		h.audit.record(ctx, audit.Event{
+			Action:  audit.ActionLoginCodeRequested,
+			Subject: req.PhoneNumber,
+			Details: auditResult(err),
+		})
+	}()
+
+	now := time.Now()
+
+	if err := h.checkLoginLimits(ctx, req.PhoneNumber, now); err != nil {
+		return hrt.Empty, err
+	}
+
+	code, err := generateLoginCode(ctx, h.store, sessions.Code{
 		PhoneNumber: req.PhoneNumber,
-		ExpiresAt:   time.Now().Add(loginCodeExpiration).Unix(),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(loginCodeExpiration),
 	})
 	if err != nil {
 		h.logger.Error(
@@ -104,7 +416,11 @@ func (h *authHandler) loginPhase1(ctx context.Context, req *twidpb.LoginPhase1Re
 
 	body := twisms.NewTextBody(fmt.Sprintf(verificationMessage, code))
 	if err := twisms.SendAutoTextMessage(ctx, h.sms, req.PhoneNumber, body); err != nil {
-		h.codes.Delete(code)
+		if err := h.store.DeleteCode(ctx, code.String()); err != nil {
+			h.logger.Error(
+				"failed to delete unsent verification code",
+				"err", err)
+		}
 		h.logger.Error(
 			"failed to send verification code",
 			"err", err)
@@ -114,23 +430,113 @@ func (h *authHandler) loginPhase1(ctx context.Context, req *twidpb.LoginPhase1Re
 	return hrt.Empty, nil
 }
 
-func (h *authHandler) loginPhase2(ctx context.Context, req *twidpb.LoginPhase2Request) (*twidpb.LoginResponse, error) {
+func (h *authHandler) loginPhase2(ctx context.Context, req *twidpb.LoginPhase2Request) (_ *twidpb.LoginResponse, err error) {
+	// secondFactor is the second factor method used, if any.
+	var secondFactor string
+	defer func() {
+		details := auditResult(err)
+		if secondFactor != "" {
+			details["second_factor"] = secondFactor
+		}
+		h.audit.record(ctx, audit.Event{
+			Action:  audit.ActionLogin,
+			Subject: req.PhoneNumber,
+			Details: details,
+		})
+	}()
+
+	if req.Cookie && !h.cookies.enabled() {
+		return nil, errCookiesDisabled
+	}
+
 	code, err := parseAuthCode(req.Code)
 	if err != nil {
 		return nil, hrt.WrapHTTPError(400, fmt.Errorf("invalid code: %w", err))
 	}
 
-	session, ok := h.codes.Load(code)
-	if !ok || session.Expired() || session.PhoneNumber != req.PhoneNumber {
+	if !h.allowedNumber(req.PhoneNumber) {
+		return nil, errLoginNotAllowed
+	}
+
+	codes, err := h.store.Codes(ctx, req.PhoneNumber)
+	if err != nil {
+		h.logger.Error(
+			"failed to load auth codes",
+			"err", err)
+		return nil, errInternal
+	}
+
+	var pending sessions.Code
+	var found bool
+	for _, c := range codes {
+		if c.Attempts < h.cfg.MaxAttempts && c.Code == code.String() {
+			pending = c
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		if err := h.store.AddCodeAttempt(ctx, req.PhoneNumber); err != nil {
+			h.logger.Error(
+				"failed to record failed login attempt",
+				"err", err)
+		}
 		return nil, errInvalidLogin
 	}
 
-	session = authSession{
-		PhoneNumber: session.PhoneNumber,
-		ExpiresAt:   time.Now().Add(sessionExpiration).Unix(),
+	enrolment, err := h.enabledTOTP(ctx, pending.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if enrolment != nil {
+		if req.SecondFactor == "" {
+			// Keep the login code, so that it can be used again along with
+			// the second factor.
+			secondFactor = "required"
+			return &twidpb.LoginResponse{SecondFactorRequired: true}, nil
+		}
+
+		secondFactor, err = h.checkSecondFactor(ctx, *enrolment, req.SecondFactor, true)
+		if err != nil {
+			if !errors.Is(err, errInvalidSecondFactor) {
+				return nil, err
+			}
+			// Wrong second factors count towards the attempts of the login
+			// code, which limits how many can be guessed.
+			if err := h.store.AddCodeAttempt(ctx, req.PhoneNumber); err != nil {
+				h.logger.Error(
+					"failed to record failed login attempt",
+					"err", err)
+			}
+			return nil, errInvalidLogin
+		}
+	}
+
+	// Codes can only be used once, even by concurrent logins.
+	if _, err := h.store.UseCode(ctx, pending.PhoneNumber, pending.Code, h.cfg.MaxAttempts); err != nil {
+		if errors.Is(err, sessions.ErrUsed) {
+			return nil, errInvalidLogin
+		}
+		h.logger.Error(
+			"failed to use auth code",
+			"err", err)
+		return nil, errInternal
 	}
 
-	token, err := generateAuthToken(h.sessions, session)
+	now := time.Now()
+	session := sessions.Session{
+		PhoneNumber:  pending.PhoneNumber,
+		CreatedAt:    now,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(sessionExpiration),
+		SecondFactor: enrolment != nil,
+	}
+	if r := hrt.RequestFromContext(ctx); r != nil {
+		session.UserAgent = r.UserAgent()
+	}
+
+	token, err := generateAuthToken(ctx, h.store, session)
 	if err != nil {
 		h.logger.Error(
 			"failed to generate auth token",
@@ -143,15 +549,32 @@ func (h *authHandler) loginPhase2(ctx context.Context, req *twidpb.LoginPhase2Re
 		"code", code,
 		"phone_number", session.PhoneNumber)
 
+	if req.Cookie {
+		csrf := h.cookies.setSession(responseWriterFromContext(ctx), token, session.ExpiresAt)
+		return &twidpb.LoginResponse{
+			ExpiresAt: timestamppb.New(session.ExpiresAt),
+			CsrfToken: csrf,
+		}, nil
+	}
+
 	return &twidpb.LoginResponse{
 		Token:     token,
-		ExpiresAt: timestamppb.New(time.Unix(session.ExpiresAt, 0)),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
 	}, nil
 }
 
 type loginCode int
 
-func generateLoginCode[T any](m *xsync.MapOf[loginCode, T], v T) (loginCode, error) {
+// remoteIP returns the IP address of the client that made the request.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func generateLoginCode(ctx context.Context, store sessions.Store, v sessions.Code) (loginCode, error) {
 	var zero loginCode
 	var seed [32]byte
 	if _, err := rand.Read(seed[:]); err != nil {
@@ -165,9 +588,12 @@ func generateLoginCode[T any](m *xsync.MapOf[loginCode, T], v T) (loginCode, err
 		const maxCode = 9_999_999
 		code := loginCode(prng.IntN(maxCode-minCode) + minCode)
 
-		_, exists := m.LoadOrStore(code, v)
-		if exists {
-			continue
+		v.Code = code.String()
+		if err := store.CreateCode(ctx, v); err != nil {
+			if errors.Is(err, sessions.ErrExists) {
+				continue
+			}
+			return zero, err
 		}
 
 		return code, nil
@@ -185,16 +611,7 @@ func (a loginCode) String() string {
 	return strconv.Itoa(int(a))
 }
 
-type authSession struct {
-	PhoneNumber string
-	ExpiresAt   int64
-}
-
-func (t authSession) Expired() bool {
-	return t.ExpiresAt < time.Now().Unix()
-}
-
-func generateAuthToken[T any](m *xsync.MapOf[string, T], v T) (string, error) {
+func generateAuthToken(ctx context.Context, store sessions.Store, v sessions.Session) (string, error) {
 	for iter := 0; iter < 1_000; iter++ {
 		var r [24]byte
 		if _, err := rand.Read(r[:]); err != nil {
@@ -202,9 +619,12 @@ func generateAuthToken[T any](m *xsync.MapOf[string, T], v T) (string, error) {
 		}
 		token := base64.URLEncoding.EncodeToString(r[:])
 
-		_, exists := m.LoadOrStore(token, v)
-		if exists {
-			continue
+		v.ID = sessions.TokenID(token)
+		if err := store.CreateSession(ctx, v); err != nil {
+			if errors.Is(err, sessions.ErrExists) {
+				continue
+			}
+			return "", err
 		}
 
 		return token, nil
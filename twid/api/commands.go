@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twisms"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"libdb.so/hrt"
+)
+
+// executeCommand runs a command as if the caller had texted it to twid. The
+// response is returned instead of being sent to the caller.
+func (h *handler) executeCommand(ctx context.Context, req *twidpb.ExecuteCommandRequest) (*twicmdproto.ExecuteResponse, error) {
+	session := sessionFromContext(ctx)
+	sendingNumber, _ := h.sms.SendingNumber()
+
+	msg := &twismsproto.Message{
+		From:      session.PhoneNumber,
+		To:        sendingNumber,
+		Timestamp: timestamppb.Now(),
+	}
+
+	var resp *twicmdproto.ExecuteResponse
+	var err error
+
+	switch input := req.Input.(type) {
+	case *twidpb.ExecuteCommandRequest_Text:
+		if input.Text == "" {
+			return nil, hrt.NewHTTPError(http.StatusBadRequest, "missing command text")
+		}
+		msg.Body = twisms.NewTextBody(input.Text)
+		resp, err = h.cmd.Execute(ctx, msg)
+
+	case *twidpb.ExecuteCommandRequest_Command:
+		if input.Command.GetService() == "" || input.Command.GetCommand() == "" {
+			return nil, hrt.NewHTTPError(http.StatusBadRequest, "missing service or command")
+		}
+		command, verr := h.validateCommand(ctx, input.Command)
+		if verr != nil {
+			return nil, verr
+		}
+		msg.Body = twisms.NewTextBody("")
+		resp, err = h.cmd.ExecuteCommand(ctx, msg, command)
+
+	default:
+		return nil, hrt.NewHTTPError(http.StatusBadRequest, "missing text or command")
+	}
+
+	if err != nil {
+		if errors.Is(err, twicmd.ErrUnknownService) {
+			return nil, hrt.NewHTTPError(http.StatusNotFound, "service not found")
+		}
+		return nil, errInternal
+	}
+
+	return resp, nil
+}
+
+// validateCommand checks the command against the description of its service.
+// It returns the command with the service and command names as described.
+func (h *handler) validateCommand(ctx context.Context, command *twicmdproto.Command) (*twicmdproto.Command, error) {
+	if command.Service == twicmd.HelpService {
+		// The help service is built in and checks its own arguments.
+		return command, nil
+	}
+
+	result, err := h.cmd.Services.MatchCommand(ctx, command.Service, command.Command, twicmd.SuggestOpts{})
+	if err != nil {
+		switch {
+		case errors.Is(err, twicmd.ErrUnknownService):
+			return nil, hrt.NewHTTPError(http.StatusNotFound, "service not found")
+		case errors.Is(err, twicmd.ErrUnknownCommand):
+			return nil, hrt.NewHTTPError(http.StatusNotFound, "command not found")
+		}
+		h.logger.Error(
+			"failed to look up command",
+			"service", command.Service,
+			"command", command.Command,
+			"err", err)
+		return nil, errInternal
+	}
+
+	for _, arg := range command.Arguments {
+		if _, ok := result.Command.Arguments[arg.Name]; !ok {
+			return nil, hrt.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown argument %q", arg.Name))
+		}
+	}
+
+	if missing := twicmd.MissingArguments(result.Command, command.Arguments); len(missing) > 0 {
+		return nil, hrt.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing required argument %q", missing[0]))
+	}
+
+	return &twicmdproto.Command{
+		Service:   result.Description.Name,
+		Command:   result.Command.Name,
+		Arguments: command.Arguments,
+	}, nil
+}
+
+// autocomplete suggests values for a command argument while the caller is
+// typing it.
+func (h *handler) autocomplete(ctx context.Context, req *twicmdproto.ArgumentAutocompleteRequest) (*twicmdproto.ArgumentAutocompleteResponse, error) {
+	if req.Service == "" || req.Command == "" || req.Argument == "" {
+		return nil, hrt.NewHTTPError(http.StatusBadRequest, "missing service, command or argument")
+	}
+
+	service, ok := h.cmd.Services.Service(req.Service)
+	if !ok {
+		return nil, hrt.NewHTTPError(http.StatusNotFound, "service not found")
+	}
+
+	autocompleter, ok := twicmd.AsAutocompleting(ctx, service)
+	if !ok {
+		return nil, hrt.NewHTTPError(http.StatusNotAcceptable, "service does not support autocompletion")
+	}
+
+	resp, err := autocompleter.Autocomplete(ctx, req)
+	if err != nil {
+		h.logger.Error(
+			"failed to autocomplete argument",
+			"service", req.Service,
+			"command", req.Command,
+			"argument", req.Argument,
+			"err", err)
+		return nil, errInternal
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// conversationBackfill is how far back the conversation stream backfills
+	// messages on connect.
+	conversationBackfill = 24 * time.Hour
+	// sseKeepAlive is the interval at which comments are sent to keep idle
+	// event streams open.
+	sseKeepAlive = 30 * time.Second
+)
+
+// streamConversation streams all messages from and to the caller's phone
+// number as server-sent events. Each event is a "message" event with a
+// twisms.Message in Protobuf JSON format as its data. Recent messages are sent
+// first.
+func (h *handler) streamConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session := sessionFromContext(ctx)
+
+	// Subscribe before backfilling so that no message is missed in between.
+	// Filters are ANDed together, so two subscriptions are needed.
+	fromCh := make(chan *twismsproto.Message)
+	h.conversations.SubscribeMessages(fromCh, &twismsproto.MessageFilters{
+		Filters: []*twismsproto.MessageFilter{
+			{Filter: &twismsproto.MessageFilter_MatchFrom{MatchFrom: session.PhoneNumber}},
+		},
+	})
+	defer h.conversations.UnsubscribeMessages(fromCh)
+
+	toCh := make(chan *twismsproto.Message)
+	h.conversations.SubscribeMessages(toCh, &twismsproto.MessageFilters{
+		Filters: []*twismsproto.MessageFilter{
+			{Filter: &twismsproto.MessageFilter_MatchTo{MatchTo: session.PhoneNumber}},
+		},
+	})
+	defer h.conversations.UnsubscribeMessages(toCh)
+
+	recent, err := h.conversations.RecentMessages(ctx, session.PhoneNumber, time.Now().Add(-conversationBackfill))
+	if err != nil {
+		h.logger.Error(
+			"failed to load recent messages",
+			"err", err)
+		writeError(w, errInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+
+	for _, msg := range recent {
+		if err := writeMessageEvent(w, msg); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var msg *twismsproto.Message
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case msg = <-fromCh:
+		case msg = <-toCh:
+		}
+
+		if msg != nil {
+			if err := writeMessageEvent(w, msg); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeMessageEvent(w http.ResponseWriter, msg *twismsproto.Message) error {
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+	return err
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/twipi/twipi/twid/config"
+	"libdb.so/ctxt"
+	"libdb.so/hrt"
+)
+
+const (
+	defaultSessionCookie = "twid_session"
+	csrfCookieSuffix     = "_csrf"
+	csrfHeader           = "X-CSRF-Token"
+)
+
+var (
+	errCookiesDisabled = hrt.NewHTTPError(http.StatusBadRequest, "cookie sessions are not enabled")
+	errInvalidCSRF     = hrt.NewHTTPError(http.StatusForbidden, "missing or invalid CSRF token")
+)
+
+// cookieSessions sets and reads session cookies. The zero value has cookie
+// sessions disabled.
+type cookieSessions struct {
+	cfg      config.CookieSessions
+	sameSite http.SameSite
+}
+
+func newCookieSessions(cfg config.CookieSessions) (cookieSessions, error) {
+	if cfg.Name == "" {
+		cfg.Name = defaultSessionCookie
+	}
+
+	c := cookieSessions{cfg: cfg}
+	switch cfg.SameSite {
+	case "", "lax":
+		c.sameSite = http.SameSiteLaxMode
+	case "strict":
+		c.sameSite = http.SameSiteStrictMode
+	case "none":
+		if cfg.Insecure {
+			// Browsers reject SameSite=None cookies that aren't secure.
+			return c, fmt.Errorf("cookie_sessions: same_site none requires secure cookies")
+		}
+		c.sameSite = http.SameSiteNoneMode
+	default:
+		return c, fmt.Errorf("cookie_sessions: unknown same_site %q", cfg.SameSite)
+	}
+
+	return c, nil
+}
+
+func (c cookieSessions) enabled() bool {
+	return c.cfg.Enabled
+}
+
+func (c cookieSessions) cookie(name, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.cfg.Domain,
+		Expires:  expires,
+		Secure:   !c.cfg.Insecure,
+		SameSite: c.sameSite,
+	}
+}
+
+// setSession sets the session cookie of the given token and its CSRF cookie.
+// It returns the CSRF token.
+func (c cookieSessions) setSession(w http.ResponseWriter, token string, expires time.Time) string {
+	csrf := csrfToken(token)
+
+	session := c.cookie(c.cfg.Name, token, expires)
+	session.HttpOnly = true
+	http.SetCookie(w, session)
+
+	// The CSRF cookie is readable by scripts, so that clients that lost the
+	// token can read it back.
+	http.SetCookie(w, c.cookie(c.cfg.Name+csrfCookieSuffix, csrf, expires))
+
+	return csrf
+}
+
+// clearSession deletes the cookies set by setSession.
+func (c cookieSessions) clearSession(w http.ResponseWriter) {
+	for _, name := range []string{c.cfg.Name, c.cfg.Name + csrfCookieSuffix} {
+		cookie := c.cookie(name, "", time.Time{})
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// sessionToken returns the token in the session cookie of the request, if
+// any.
+func (c cookieSessions) sessionToken(r *http.Request) string {
+	if !c.enabled() {
+		return ""
+	}
+	cookie, err := r.Cookie(c.cfg.Name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// checkCSRF checks that a request authenticated using the session cookie of
+// the given token carries its CSRF token. Requests that only read data don't
+// need to.
+func checkCSRF(r *http.Request, token string) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	want := csrfToken(token)
+	got := r.Header.Get(csrfHeader)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return errInvalidCSRF
+	}
+	return nil
+}
+
+// csrfToken derives the CSRF token of a session from its token. Only those
+// who know the session token, which is kept in an HttpOnly cookie, can derive
+// it, so it doesn't need to be stored.
+func csrfToken(token string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte("csrf"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// withResponseWriter is a middleware that makes the response writer available
+// to hrt handlers, which only get the context, so that they can set cookies.
+func withResponseWriter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ctxt.With(r.Context(), responseWriter{w})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type responseWriter struct{ http.ResponseWriter }
+
+// responseWriterFromContext returns the response writer of the request. It
+// must only be used behind withResponseWriter.
+func responseWriterFromContext(ctx context.Context) http.ResponseWriter {
+	w, _ := ctxt.From[responseWriter](ctx)
+	return w.ResponseWriter
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies is a list of reverse proxies whose forwarding headers are
+// trusted.
+type trustedProxies []netip.Prefix
+
+// parseTrustedProxies parses a list of IP addresses and CIDR ranges.
+func parseTrustedProxies(proxies []string) (trustedProxies, error) {
+	prefixes := make(trustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func (p trustedProxies) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP address of the client that the request was made
+// for. Forwarding headers are only used if the request came from a trusted
+// proxy. X-Forwarded-For is read from the right, skipping trusted proxies,
+// since clients can put anything on its left.
+func (p trustedProxies) clientIP(r *http.Request) (netip.Addr, bool) {
+	addr, ok := parseRemoteAddr(r.RemoteAddr)
+	if !ok || !p.trusts(addr) {
+		return netip.Addr{}, false
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Everything left of a malformed hop is untrustworthy.
+			break
+		}
+		client = hop
+		if !p.trusts(hop) {
+			return client, true
+		}
+	}
+	if client.IsValid() {
+		// Every hop is a trusted proxy, so the leftmost one is the client.
+		return client, true
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP, true
+	}
+
+	return netip.Addr{}, false
+}
+
+// realIPMiddleware returns a middleware that replaces the remote address of
+// requests made by trusted proxies with the address of the client, so that
+// rate limits and audit events apply to the client rather than the proxy. It
+// returns nil if no proxies are trusted, in which case forwarding headers are
+// ignored.
+func realIPMiddleware(proxies []string) (func(http.Handler) http.Handler, error) {
+	if len(proxies) == 0 {
+		return nil, nil
+	}
+
+	trusted, err := parseTrustedProxies(proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if client, ok := trusted.clientIP(r); ok {
+				r.RemoteAddr = net.JoinHostPort(client.Unmap().String(), "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return addr, err == nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		ip         string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "203.0.113.7:1234",
+			ip:         "203.0.113.7",
+		},
+		{
+			name:       "untrusted proxy",
+			remoteAddr: "203.0.113.7:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			ip:         "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			ip:         "198.51.100.1",
+		},
+		{
+			name:       "spoofed hops",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1, 10.0.0.3"},
+			ip:         "198.51.100.1",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"},
+			ip:         "10.0.0.4",
+		},
+		{
+			name:       "real ip",
+			remoteAddr: "[::1]:1234",
+			headers:    map[string]string{"X-Real-IP": "2001:db8::1"},
+			ip:         "2001:db8::1",
+		},
+		{
+			name:       "no headers",
+			remoteAddr: "10.0.0.2:1234",
+			ip:         "10.0.0.2",
+		},
+	}
+
+	realIP, err := realIPMiddleware([]string{"10.0.0.0/8", "::1"})
+	assert.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ip string
+			handler := realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = remoteIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, test.ip, ip)
+		})
+	}
+}
+
+func TestRealIPMiddleware_invalid(t *testing.T) {
+	_, err := realIPMiddleware([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	_, err = realIPMiddleware([]string{"proxy.example.com"})
+	assert.Error(t, err)
+
+	realIP, err := realIPMiddleware(nil)
+	assert.NoError(t, err)
+	assert.Zero(t, realIP)
+}
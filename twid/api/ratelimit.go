@@ -0,0 +1,60 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a fixed-window rate limiter keyed by arbitrary strings, such
+// as IP addresses or phone numbers.
+type rateLimiter struct {
+	count  int
+	period time.Duration
+
+	mu        sync.Mutex
+	windows   map[string]rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(count int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		count:   count,
+		period:  period,
+		windows: make(map[string]rateWindow),
+	}
+}
+
+// allow reports whether the action identified by key may happen now. If it
+// may, the action is counted against the key's limit.
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Sweep old windows every period so that the map doesn't grow forever.
+	if now.Sub(l.lastSweep) > l.period {
+		for k, w := range l.windows {
+			if now.Sub(w.start) > l.period {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) > l.period {
+		w = rateWindow{start: now}
+	}
+
+	if w.count >= l.count {
+		return false
+	}
+
+	w.count++
+	l.windows[key] = w
+	return true
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	assert.True(t, l.allow("a", now))
+	assert.True(t, l.allow("a", now.Add(time.Second)))
+	assert.False(t, l.allow("a", now.Add(2*time.Second)))
+	assert.True(t, l.allow("b", now.Add(2*time.Second)))
+
+	// The window of "a" has passed, so it's allowed again.
+	assert.True(t, l.allow("a", now.Add(2*time.Minute)))
+	assert.Equal(t, 1, len(l.windows))
+}
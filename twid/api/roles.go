@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/twipi/twipi/internal/openapi"
+	"github.com/twipi/twipi/twid/sessions"
+	"libdb.so/ctxt"
+	"libdb.so/hrt"
+)
+
+// role is the role of a caller. Roles are ordered, so a role includes all
+// roles before it.
+type role int
+
+const (
+	roleUser role = iota
+	roleAdmin
+)
+
+func (r role) String() string {
+	switch r {
+	case roleUser:
+		return "user"
+	case roleAdmin:
+		return "admin"
+	default:
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+}
+
+// roleOf returns the role of the caller. Sessions are admins if their phone
+// number is configured as an admin, and API keys are admins if they have the
+// admin scope.
+func (h *authHandler) roleOf(caller authCaller) role {
+	if caller.APIKey != nil {
+		if caller.APIKey.HasScope(sessions.ScopeAdmin) {
+			return roleAdmin
+		}
+		return roleUser
+	}
+	if slices.Contains(h.admins, caller.Session.PhoneNumber) {
+		return roleAdmin
+	}
+	return roleUser
+}
+
+// allowedNumber returns true if the phone number may log in according to the
+// allowlist and denylist.
+func (h *authHandler) allowedNumber(phoneNumber string) bool {
+	if slices.Contains(h.cfg.Deny, phoneNumber) {
+		return false
+	}
+	if len(h.cfg.Allow) == 0 || slices.Contains(h.admins, phoneNumber) {
+		return true
+	}
+	return slices.Contains(h.cfg.Allow, phoneNumber)
+}
+
+// requireRole returns a middleware that only allows callers with at least the
+// given role. It must be used after authMiddleware.
+func requireRole(role role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return openapi.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, _ := ctxt.From[authCaller](r.Context())
+			if caller.Role < role {
+				writeError(w, hrt.NewHTTPError(http.StatusForbidden, fmt.Sprintf("role %s required", role)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}), openapi.Requirement{
+			Roles:       []string{role.String()},
+			Description: fmt.Sprintf("Requires the %s role.", role),
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/cors"
+	"github.com/twipi/twipi/twid/config"
+)
+
+var defaultCORSMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// defaultContentSecurityPolicy forbids everything, since the API only serves
+// data.
+const defaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// corsMiddleware returns a middleware that implements CORS as configured. It
+// returns nil if no origins are allowed, in which case browsers only allow
+// same-origin requests.
+func corsMiddleware(cfg config.CORS) (func(http.Handler) http.Handler, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		return nil, errors.New("cors: allow_credentials can't be used with all origins allowed")
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   methods,
+		AllowedHeaders:   []string{"Authorization", "Content-Type", csrfHeader},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.AsDuration().Seconds()),
+	}), nil
+}
+
+// SecurityHeaders returns a middleware that sets the configured security
+// headers on every response, like the API does, but with the given
+// Content-Security-Policy. It is meant for pages served next to the API, such
+// as the web UI.
+func SecurityHeaders(cfg config.SecurityHeaders, contentSecurityPolicy string) func(http.Handler) http.Handler {
+	cfg.ContentSecurityPolicy = contentSecurityPolicy
+	return securityHeaders(cfg)
+}
+
+// securityHeaders returns a middleware that sets the configured security
+// headers on every response.
+func securityHeaders(cfg config.SecurityHeaders) func(http.Handler) http.Handler {
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "no-referrer",
+		"Content-Security-Policy": defaultContentSecurityPolicy,
+		// Responses may contain private data.
+		"Cache-Control": "no-store",
+	}
+	if cfg.ContentSecurityPolicy != "" {
+		headers["Content-Security-Policy"] = cfg.ContentSecurityPolicy
+	}
+	if maxAge := cfg.HSTSMaxAge.AsDuration(); maxAge > 0 {
+		headers["Strict-Transport-Security"] = "max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+
+	return func(next http.Handler) http.Handler {
+		if cfg.Disable {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/twid/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	const csp = "default-src 'self'"
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		cfg     config.SecurityHeaders
+		headers map[string]string
+	}{
+		{
+			name: "default",
+			cfg:  config.SecurityHeaders{ContentSecurityPolicy: "default-src 'none'"},
+			headers: map[string]string{
+				"Content-Security-Policy": csp,
+				"X-Content-Type-Options":  "nosniff",
+				"X-Frame-Options":         "DENY",
+			},
+		},
+		{
+			name: "disabled",
+			cfg:  config.SecurityHeaders{Disable: true},
+			headers: map[string]string{
+				"Content-Security-Policy": "",
+				"X-Content-Type-Options":  "",
+				"X-Frame-Options":         "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SecurityHeaders(test.cfg, csp)(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			for k, v := range test.headers {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
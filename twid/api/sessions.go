@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/sessions"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"libdb.so/ctxt"
+	"libdb.so/hrt"
+)
+
+var errSessionNotFound = hrt.NewHTTPError(http.StatusNotFound, "session not found")
+
+// sessionFromContext returns the session of the caller. It must only be used
+// behind requireSession.
+func sessionFromContext(ctx context.Context) *sessions.Session {
+	caller, _ := ctxt.From[authCaller](ctx)
+	return caller.Session
+}
+
+func (h *authHandler) logout(ctx context.Context, _ hrt.None) (hrt.None, error) {
+	session := sessionFromContext(ctx)
+
+	if err := h.store.DeleteSession(ctx, session.ID); err != nil {
+		h.logger.Error(
+			"failed to delete session",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	if h.cookies.enabled() {
+		h.cookies.clearSession(responseWriterFromContext(ctx))
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionSessionRevoked,
+		Subject: session.PhoneNumber,
+		Details: map[string]string{"session": session.ID, "reason": "logout"},
+	})
+
+	return hrt.Empty, nil
+}
+
+func (h *authHandler) listSessions(ctx context.Context, req *twidpb.ListSessionsRequest) (*twidpb.ListSessionsResponse, error) {
+	current := sessionFromContext(ctx)
+
+	list, err := h.store.Sessions(ctx, current.PhoneNumber)
+	if err != nil {
+		h.logger.Error(
+			"failed to list sessions",
+			"err", err)
+		return nil, errInternal
+	}
+
+	resp := &twidpb.ListSessionsResponse{
+		Sessions: make([]*twidpb.Session, len(list)),
+	}
+	for i, session := range list {
+		resp.Sessions[i] = &twidpb.Session{
+			Id:           session.ID,
+			UserAgent:    session.UserAgent,
+			CreatedAt:    timestamppb.New(session.CreatedAt),
+			LastUsedAt:   timestamppb.New(session.LastUsedAt),
+			ExpiresAt:    timestamppb.New(session.ExpiresAt),
+			Current:      session.ID == current.ID,
+			SecondFactor: session.SecondFactor,
+		}
+	}
+
+	return resp, nil
+}
+
+func (h *authHandler) revokeSession(ctx context.Context, _ hrt.None) (hrt.None, error) {
+	current := sessionFromContext(ctx)
+	id := chi.URLParamFromCtx(ctx, "id")
+
+	session, err := h.store.Session(ctx, id)
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return hrt.Empty, errSessionNotFound
+		}
+		h.logger.Error(
+			"failed to load session",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	// Don't reveal sessions of other users.
+	if session.PhoneNumber != current.PhoneNumber {
+		return hrt.Empty, errSessionNotFound
+	}
+
+	if err := h.store.DeleteSession(ctx, session.ID); err != nil {
+		h.logger.Error(
+			"failed to delete session",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionSessionRevoked,
+		Subject: session.PhoneNumber,
+		Details: map[string]string{"session": session.ID, "reason": "revoked"},
+	})
+
+	return hrt.Empty, nil
+}
+
+func (h *authHandler) revokeOtherSessions(ctx context.Context, _ hrt.None) (hrt.None, error) {
+	current := sessionFromContext(ctx)
+
+	if err := h.store.DeleteOtherSessions(ctx, current.PhoneNumber, current.ID); err != nil {
+		h.logger.Error(
+			"failed to delete other sessions",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionSessionRevoked,
+		Subject: current.PhoneNumber,
+		Details: map[string]string{"session": "*", "reason": "revoked_others"},
+	})
+
+	return hrt.Empty, nil
+}
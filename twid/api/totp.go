@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/twipi/twipi/internal/totp"
+	"github.com/twipi/twipi/proto/out/twidpb"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/sessions"
+	"libdb.so/hrt"
+)
+
+const (
+	// totpIssuer is the issuer shown by authenticator apps.
+	totpIssuer = "Twipi"
+	// totpAttempts is how many TOTP codes a phone number may enter per
+	// totpAttemptPeriod outside of logging in, where login codes already
+	// limit the attempts.
+	totpAttempts      = 5
+	totpAttemptPeriod = 5 * time.Minute
+)
+
+var (
+	errInvalidSecondFactor = hrt.NewHTTPError(http.StatusBadRequest, "invalid TOTP or recovery code")
+	errTOTPNotEnrolled     = hrt.NewHTTPError(http.StatusNotFound, "TOTP is not enrolled")
+	errTOTPNotEnabled      = hrt.NewHTTPError(http.StatusNotFound, "TOTP is not enabled")
+	errTOTPEnabled         = hrt.NewHTTPError(http.StatusConflict, "TOTP is already enabled")
+	errSecondFactorNeeded  = hrt.NewHTTPError(http.StatusForbidden, "sensitive options require logging in using TOTP")
+)
+
+// Second factor methods, as recorded in the audit log.
+const (
+	secondFactorTOTP         = "totp"
+	secondFactorRecoveryCode = "recovery_code"
+)
+
+// enabledTOTP returns the confirmed TOTP enrolment of the phone number, or nil
+// if it doesn't have TOTP enabled.
+func (h *authHandler) enabledTOTP(ctx context.Context, phoneNumber string) (*sessions.TOTP, error) {
+	enrolment, err := h.store.TOTP(ctx, phoneNumber)
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return nil, nil
+		}
+		h.logger.Error(
+			"failed to load TOTP enrolment",
+			"err", err)
+		return nil, errInternal
+	}
+	if !enrolment.Confirmed {
+		return nil, nil
+	}
+	return &enrolment, nil
+}
+
+// checkSecondFactor checks a TOTP code, or a recovery code if allowRecovery is
+// true, and marks it as used. It returns the method that was used, or
+// errInvalidSecondFactor.
+func (h *authHandler) checkSecondFactor(ctx context.Context, enrolment sessions.TOTP, code string, allowRecovery bool) (string, error) {
+	if step, ok := totp.Validate(enrolment.Secret, code, time.Now()); ok {
+		if err := h.store.UseTOTPStep(ctx, enrolment.PhoneNumber, step); err != nil {
+			if errors.Is(err, sessions.ErrUsed) {
+				return "", errInvalidSecondFactor
+			}
+			h.logger.Error(
+				"failed to record used TOTP code",
+				"err", err)
+			return "", errInternal
+		}
+		return secondFactorTOTP, nil
+	}
+
+	if !allowRecovery || code == "" {
+		return "", errInvalidSecondFactor
+	}
+
+	if err := h.store.UseRecoveryCode(ctx, enrolment.PhoneNumber, sessions.RecoveryCodeID(code)); err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return "", errInvalidSecondFactor
+		}
+		h.logger.Error(
+			"failed to use recovery code",
+			"err", err)
+		return "", errInternal
+	}
+	return secondFactorRecoveryCode, nil
+}
+
+// allowTOTPAttempt returns an error if the phone number has guessed too many
+// codes recently. Codes are short enough to be guessed otherwise.
+func (h *authHandler) allowTOTPAttempt(phoneNumber string) error {
+	if !h.totpLimiter.allow(phoneNumber, time.Now()) {
+		return errTooManyRequests
+	}
+	return nil
+}
+
+// mayAccessSensitive returns true if the caller may view and change sensitive
+// options.
+func (h *authHandler) mayAccessSensitive(caller authCaller) bool {
+	return !h.cfg.RequireTOTPForSensitive || caller.Session == nil || caller.Session.SecondFactor
+}
+
+func (h *authHandler) getTOTP(ctx context.Context, req *twidpb.GetTOTPRequest) (*twidpb.GetTOTPResponse, error) {
+	session := sessionFromContext(ctx)
+
+	enrolment, err := h.enabledTOTP(ctx, session.PhoneNumber)
+	if err != nil || enrolment == nil {
+		return &twidpb.GetTOTPResponse{}, err
+	}
+
+	left, err := h.store.RecoveryCodesLeft(ctx, session.PhoneNumber)
+	if err != nil {
+		h.logger.Error(
+			"failed to count recovery codes",
+			"err", err)
+		return nil, errInternal
+	}
+
+	return &twidpb.GetTOTPResponse{
+		Enabled:           true,
+		RecoveryCodesLeft: int32(left),
+	}, nil
+}
+
+func (h *authHandler) enrollTOTP(ctx context.Context, req *twidpb.EnrollTOTPRequest) (*twidpb.EnrollTOTPResponse, error) {
+	session := sessionFromContext(ctx)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		h.logger.Error(
+			"failed to generate TOTP secret",
+			"err", err)
+		return nil, errInternal
+	}
+
+	err = h.store.CreateTOTP(ctx, sessions.TOTP{
+		PhoneNumber: session.PhoneNumber,
+		Secret:      secret,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, sessions.ErrExists) {
+			return nil, errTOTPEnabled
+		}
+		h.logger.Error(
+			"failed to create TOTP enrolment",
+			"err", err)
+		return nil, errInternal
+	}
+
+	return &twidpb.EnrollTOTPResponse{
+		Secret:          totp.Encoding.EncodeToString(secret),
+		ProvisioningUri: totp.ProvisioningURI(totpIssuer, session.PhoneNumber, secret),
+	}, nil
+}
+
+func (h *authHandler) confirmTOTP(ctx context.Context, req *twidpb.TOTPCodeRequest) (*twidpb.RecoveryCodesResponse, error) {
+	session := sessionFromContext(ctx)
+
+	if err := h.allowTOTPAttempt(session.PhoneNumber); err != nil {
+		return nil, err
+	}
+
+	enrolment, err := h.store.TOTP(ctx, session.PhoneNumber)
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			return nil, errTOTPNotEnrolled
+		}
+		h.logger.Error(
+			"failed to load TOTP enrolment",
+			"err", err)
+		return nil, errInternal
+	}
+	if enrolment.Confirmed {
+		return nil, errTOTPEnabled
+	}
+
+	if _, err := h.checkSecondFactor(ctx, enrolment, req.Code, false); err != nil {
+		return nil, err
+	}
+
+	codes, ids, err := newRecoveryCodes()
+	if err != nil {
+		h.logger.Error(
+			"failed to generate recovery codes",
+			"err", err)
+		return nil, errInternal
+	}
+
+	if err := h.store.ConfirmTOTP(ctx, session.PhoneNumber, ids); err != nil {
+		h.logger.Error(
+			"failed to confirm TOTP enrolment",
+			"err", err)
+		return nil, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionTOTPEnabled,
+		Subject: session.PhoneNumber,
+	})
+
+	return &twidpb.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (h *authHandler) regenerateRecoveryCodes(ctx context.Context, req *twidpb.TOTPCodeRequest) (*twidpb.RecoveryCodesResponse, error) {
+	session := sessionFromContext(ctx)
+
+	method, err := h.requireEnabledTOTP(ctx, session.PhoneNumber, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, ids, err := newRecoveryCodes()
+	if err != nil {
+		h.logger.Error(
+			"failed to generate recovery codes",
+			"err", err)
+		return nil, errInternal
+	}
+
+	if err := h.store.SetRecoveryCodes(ctx, session.PhoneNumber, ids); err != nil {
+		h.logger.Error(
+			"failed to store recovery codes",
+			"err", err)
+		return nil, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionRecoveryCodesRegenerated,
+		Subject: session.PhoneNumber,
+		Details: map[string]string{"second_factor": method},
+	})
+
+	return &twidpb.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (h *authHandler) disableTOTP(ctx context.Context, req *twidpb.TOTPCodeRequest) (hrt.None, error) {
+	session := sessionFromContext(ctx)
+
+	method, err := h.requireEnabledTOTP(ctx, session.PhoneNumber, req.Code)
+	if err != nil {
+		return hrt.Empty, err
+	}
+
+	if err := h.store.DeleteTOTP(ctx, session.PhoneNumber); err != nil && !errors.Is(err, sessions.ErrNotFound) {
+		h.logger.Error(
+			"failed to delete TOTP enrolment",
+			"err", err)
+		return hrt.Empty, errInternal
+	}
+
+	h.audit.record(ctx, audit.Event{
+		Action:  audit.ActionTOTPDisabled,
+		Subject: session.PhoneNumber,
+		Details: map[string]string{"second_factor": method},
+	})
+
+	return hrt.Empty, nil
+}
+
+// requireEnabledTOTP checks that the phone number has TOTP enabled and that
+// the code is a valid TOTP or recovery code for it.
+func (h *authHandler) requireEnabledTOTP(ctx context.Context, phoneNumber, code string) (string, error) {
+	if err := h.allowTOTPAttempt(phoneNumber); err != nil {
+		return "", err
+	}
+
+	enrolment, err := h.enabledTOTP(ctx, phoneNumber)
+	if err != nil {
+		return "", err
+	}
+	if enrolment == nil {
+		return "", errTOTPNotEnabled
+	}
+
+	return h.checkSecondFactor(ctx, *enrolment, code, true)
+}
+
+// newRecoveryCodes generates recovery codes and returns them along with their
+// IDs, which are what is stored.
+func newRecoveryCodes() (codes, ids []string, err error) {
+	codes, err = sessions.NewRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids = make([]string, len(codes))
+	for i, code := range codes {
+		ids[i] = sessions.RecoveryCodeID(code)
+	}
+
+	return codes, ids, nil
+}
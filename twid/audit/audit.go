@@ -0,0 +1,106 @@
+// Package audit provides an append-only log of logins, session revocations,
+// configuration changes and admin actions.
+package audit
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Action is the kind of an audited event.
+type Action string
+
+const (
+	// ActionLoginCodeRequested is recorded for every request for a login code
+	// (login phase 1), successful or not.
+	ActionLoginCodeRequested Action = "login.code_requested"
+	// ActionLogin is recorded for every attempt to log in using a login code
+	// (login phase 2), successful or not.
+	ActionLogin Action = "login"
+	// ActionSessionRevoked is recorded when sessions are logged out or
+	// revoked.
+	ActionSessionRevoked Action = "session.revoked"
+	// ActionConfigApplied is recorded when control panel values are applied.
+	ActionConfigApplied Action = "config.applied"
+	// ActionAPIKeyCreated is recorded when an API key is created.
+	ActionAPIKeyCreated Action = "apikey.created"
+	// ActionAPIKeyRevoked is recorded when an API key is revoked.
+	ActionAPIKeyRevoked Action = "apikey.revoked"
+	// ActionTOTPEnabled is recorded when a phone number confirms its TOTP
+	// enrolment.
+	ActionTOTPEnabled Action = "totp.enabled"
+	// ActionTOTPDisabled is recorded when a phone number disables TOTP.
+	ActionTOTPDisabled Action = "totp.disabled"
+	// ActionRecoveryCodesRegenerated is recorded when a phone number replaces
+	// its recovery codes.
+	ActionRecoveryCodesRegenerated Action = "totp.recovery_codes_regenerated"
+	// ActionServiceUpdated is recorded when an admin disables, enables or
+	// reloads a service.
+	ActionServiceUpdated Action = "service.updated"
+)
+
+// Event is a single entry in the audit log.
+type Event struct {
+	// ID identifies the event. It is assigned by the log and increases with
+	// every event.
+	ID   int64
+	Time time.Time
+	// Action is what happened.
+	Action Action
+	// Actor is who performed the action. It is a phone number for sessions,
+	// "apikey:<name>" for API keys and empty for unauthenticated requests.
+	Actor string
+	// Subject is the phone number that the action was performed on, if any.
+	Subject string
+	// IP is the IP address that the action came from, if any.
+	IP string
+	// Admin is true if the action was performed using the admin role.
+	Admin bool
+	// Details contains additional information specific to the action, such as
+	// the outcome of a login attempt.
+	Details map[string]string
+	// Changes lists the values changed by the action.
+	Changes []Change
+}
+
+// Change is a single value that was changed by an action.
+type Change struct {
+	// Key identifies the value, such as the ID of a control panel option.
+	Key string
+	Old string
+	New string
+}
+
+// MaskedValue replaces sensitive values in [Change].
+const MaskedValue = "********"
+
+// Query filters the events returned by [Log.Events]. Zero fields match
+// everything.
+type Query struct {
+	Action  Action
+	Actor   string
+	Subject string
+	// Admin only matches events performed using the admin role.
+	Admin bool
+	// Since only matches events at or after this time.
+	Since time.Time
+	// Until only matches events before this time.
+	Until time.Time
+	// BeforeID only matches events older than the event with this ID. It is
+	// used to fetch the next page.
+	BeforeID int64
+	// Limit is the maximum number of events to return.
+	Limit int
+}
+
+// Log is an append-only audit log.
+type Log interface {
+	io.Closer
+
+	// Record appends the event to the log. Its ID is assigned by the log, and
+	// its time defaults to now.
+	Record(ctx context.Context, event Event) error
+	// Events returns the events matching the query, newest first.
+	Events(ctx context.Context, query Query) ([]Event, error)
+}
@@ -0,0 +1,16 @@
+-- name: InsertEvent :one
+INSERT INTO audit_events (created_at, action, actor, subject, ip, admin, details, changes)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: Events :many
+SELECT * FROM audit_events
+WHERE (@action = '' OR action = @action)
+	AND (@actor = '' OR actor = @actor)
+	AND (@subject = '' OR subject = @subject)
+	AND (@admin_only = FALSE OR admin)
+	AND (@since = 0 OR created_at >= @since)
+	AND (@until = 0 OR created_at < @until)
+	AND (@before_id = 0 OR id < @before_id)
+ORDER BY id DESC
+LIMIT @limit;
@@ -0,0 +1,28 @@
+CREATE TABLE audit_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	admin BOOLEAN NOT NULL,
+	-- details is a JSON object of strings.
+	details TEXT NOT NULL,
+	-- changes is a JSON array of {"key", "old", "new"} objects.
+	changes TEXT NOT NULL
+);
+
+CREATE INDEX audit_events_created_at_idx ON audit_events(created_at);
+CREATE INDEX audit_events_action_idx ON audit_events(action);
+CREATE INDEX audit_events_actor_idx ON audit_events(actor);
+CREATE INDEX audit_events_subject_idx ON audit_events(subject);
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit log is append-only');
+END;
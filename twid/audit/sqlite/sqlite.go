@@ -0,0 +1,147 @@
+// Package sqlite implements a SQLite storage backend for the audit log.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_ "embed"
+
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/audit/sqlite/queries"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+//go:embed schema.sql
+var schema string
+
+// AuditLog is the SQLite storage backend for the audit log.
+type AuditLog struct {
+	db     *sql.DB
+	q      *queries.Queries
+	logger *slog.Logger
+}
+
+var _ audit.Log = (*AuditLog)(nil)
+
+// NewAuditLog creates a new SQLite audit log within the given storage.
+func NewAuditLog(ctx context.Context, storage *storage.Storage, logger *slog.Logger) (*AuditLog, error) {
+	db, err := storage.OpenSQLite(ctx, "audit", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditLog{
+		db:     db,
+		q:      queries.New(db),
+		logger: logger,
+	}, nil
+}
+
+// Close closes the database.
+func (l *AuditLog) Close() error {
+	return l.db.Close()
+}
+
+type jsonChange struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Record implements [audit.Log].
+func (l *AuditLog) Record(ctx context.Context, event audit.Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	details := event.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("could not encode details: %w", err)
+	}
+
+	changes := make([]jsonChange, len(event.Changes))
+	for i, change := range event.Changes {
+		changes[i] = jsonChange(change)
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("could not encode changes: %w", err)
+	}
+
+	_, err = l.q.InsertEvent(ctx, queries.InsertEventParams{
+		CreatedAt: event.Time.Unix(),
+		Action:    string(event.Action),
+		Actor:     event.Actor,
+		Subject:   event.Subject,
+		Ip:        event.IP,
+		Admin:     event.Admin,
+		Details:   string(detailsJSON),
+		Changes:   string(changesJSON),
+	})
+	return err
+}
+
+// Events implements [audit.Log].
+func (l *AuditLog) Events(ctx context.Context, query audit.Query) ([]audit.Event, error) {
+	params := queries.EventsParams{
+		Action:    string(query.Action),
+		Actor:     query.Actor,
+		Subject:   query.Subject,
+		AdminOnly: query.Admin,
+		BeforeID:  query.BeforeID,
+		Limit:     int64(query.Limit),
+	}
+	if !query.Since.IsZero() {
+		params.Since = query.Since.Unix()
+	}
+	if !query.Until.IsZero() {
+		params.Until = query.Until.Unix()
+	}
+	if params.Limit <= 0 {
+		// A negative limit means no limit in SQLite.
+		params.Limit = -1
+	}
+
+	rows, err := l.q.Events(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]audit.Event, len(rows))
+	for i, row := range rows {
+		event := audit.Event{
+			ID:      row.ID,
+			Time:    time.Unix(row.CreatedAt, 0),
+			Action:  audit.Action(row.Action),
+			Actor:   row.Actor,
+			Subject: row.Subject,
+			IP:      row.Ip,
+			Admin:   row.Admin,
+		}
+
+		if err := json.Unmarshal([]byte(row.Details), &event.Details); err != nil {
+			return nil, fmt.Errorf("event %d: could not decode details: %w", row.ID, err)
+		}
+
+		var changes []jsonChange
+		if err := json.Unmarshal([]byte(row.Changes), &changes); err != nil {
+			return nil, fmt.Errorf("event %d: could not decode changes: %w", row.ID, err)
+		}
+		for _, change := range changes {
+			event.Changes = append(event.Changes, audit.Change(change))
+		}
+
+		events[i] = event
+	}
+
+	return events, nil
+}
@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/twid/audit"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	storage := storage.New(config.Storage{Path: t.TempDir()}, logger)
+
+	log, err := NewAuditLog(ctx, storage, logger)
+	assert.NoError(t, err)
+
+	now := time.Unix(time.Now().Unix(), 0)
+	login := audit.Event{
+		Time:    now.Add(-time.Hour),
+		Action:  audit.ActionLogin,
+		Subject: "+15555550123",
+		IP:      "127.0.0.1",
+		Details: map[string]string{"result": "ok"},
+	}
+	applied := audit.Event{
+		Time:    now,
+		Action:  audit.ActionConfigApplied,
+		Actor:   "+15555550100",
+		Subject: "+15555550123",
+		Admin:   true,
+		Details: map[string]string{"service": "echo"},
+		Changes: []audit.Change{{Key: "token", Old: audit.MaskedValue, New: audit.MaskedValue}},
+	}
+	assert.NoError(t, log.Record(ctx, login))
+	assert.NoError(t, log.Record(ctx, applied))
+
+	events, err := log.Events(ctx, audit.Query{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events))
+
+	// Newest first.
+	login.ID = events[1].ID
+	applied.ID = events[0].ID
+	assert.Equal(t, []audit.Event{applied, login}, events)
+
+	events, err = log.Events(ctx, audit.Query{Subject: "+15555550123", Admin: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Event{applied}, events)
+
+	events, err = log.Events(ctx, audit.Query{Until: now})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Event{login}, events)
+
+	// Paging.
+	events, err = log.Events(ctx, audit.Query{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Event{applied}, events)
+
+	events, err = log.Events(ctx, audit.Query{Limit: 1, BeforeID: events[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Event{login}, events)
+
+	// The log can't be changed.
+	_, err = log.db.ExecContext(ctx, "DELETE FROM audit_events")
+	assert.Error(t, err)
+	_, err = log.db.ExecContext(ctx, "UPDATE audit_events SET actor = ''")
+	assert.Error(t, err)
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/twipi/cfgutil"
+)
+
+// redactedValue replaces any configuration value that contains a secret.
+const redactedValue = "[REDACTED]"
+
+// minSecretLength is the minimum length of a resolved value for it to be
+// redacted wherever it appears. Shorter values, such as ports, would match
+// unrelated values, so they are only redacted from the fields that reference
+// them.
+const minSecretLength = 8
+
+// ParseFile parses the configuration file at the given path. The file
+// extension is used to determine the config format.
+//
+// All string values in the configuration may contain references that are
+// resolved while parsing:
+//
+//   - ${NAME} is replaced with the value of the environment variable NAME.
+//   - ${file:PATH} is replaced with the contents of the file at PATH, minus the
+//     trailing newline. Relative paths are relative to the configuration file.
+//   - $${ is replaced with a literal ${.
+//
+// It is an error for a reference to not resolve. Resolved values are treated
+// as secrets whenever the configuration is logged or marshaled back into JSON:
+// the fields that contain references are redacted, and so is any other string
+// that contains a resolved value of at least 8 characters.
+func ParseFile(path string) (*Root, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var tree any
+	if err := cfgutil.Parse(f, strings.TrimPrefix(filepath.Ext(path), "."), &tree); err != nil {
+		return nil, err
+	}
+
+	i := interpolator{dir: filepath.Dir(path)}
+	tree, err = i.interpolate(tree, "")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal interpolated config: %w", err)
+	}
+
+	var root Root
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+
+	root.setSecrets(i.secrets)
+	return &root, nil
+}
+
+// secrets describes what to redact from the configuration.
+type secrets struct {
+	// paths is the list of paths of the fields that contain references, e.g.
+	// "twisms.services[0].token".
+	paths []string
+	// values is the list of resolved values that are long enough to be
+	// redacted wherever they appear.
+	values []string
+}
+
+func (s secrets) empty() bool {
+	return len(s.paths) == 0 && len(s.values) == 0
+}
+
+type interpolator struct {
+	dir     string
+	secrets secrets
+}
+
+func (i *interpolator) interpolate(v any, path string) (any, error) {
+	switch v := v.(type) {
+	case string:
+		s, resolved, err := i.interpolateString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if resolved {
+			i.secrets.paths = append(i.secrets.paths, path)
+		}
+		return s, nil
+
+	case map[string]any:
+		for k, elem := range v {
+			elem, err := i.interpolate(elem, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = elem
+		}
+		return v, nil
+
+	case []any:
+		for j, elem := range v {
+			elem, err := i.interpolate(elem, fmt.Sprintf("%s[%d]", path, j))
+			if err != nil {
+				return nil, err
+			}
+			v[j] = elem
+		}
+		return v, nil
+
+	default:
+		return v, nil
+	}
+}
+
+// interpolateString resolves the references in s. resolved is true if s
+// contains any reference, as opposed to only escaped ones.
+func (i *interpolator) interpolateString(s string) (_ string, resolved bool, err error) {
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start == -1 {
+			b.WriteString(s)
+			return b.String(), resolved, nil
+		}
+
+		// $${ escapes the reference.
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1])
+			b.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end == -1 {
+			return "", false, fmt.Errorf("unterminated reference in %q", s)
+		}
+		end += start
+
+		value, err := i.resolve(s[start+2 : end])
+		if err != nil {
+			return "", false, err
+		}
+		resolved = true
+
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[end+1:]
+	}
+}
+
+func (i *interpolator) resolve(ref string) (string, error) {
+	var value string
+
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(i.dir, path)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unresolved file reference: %w", err)
+		}
+
+		value = strings.TrimSuffix(string(b), "\n")
+	} else {
+		v, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("unresolved environment variable %q", ref)
+		}
+		value = v
+	}
+
+	if len(value) >= minSecretLength && !slices.Contains(i.secrets.values, value) {
+		i.secrets.values = append(i.secrets.values, value)
+	}
+
+	return value, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// redactJSON returns a copy of the given JSON document with the secrets
+// replaced with [redactedValue]. The document is the value at the given path
+// of the configuration, which is empty for the whole configuration.
+func redactJSON(b []byte, secrets secrets, path string) []byte {
+	if secrets.empty() || len(b) == 0 {
+		return b
+	}
+
+	var tree any
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&tree); err != nil {
+		// Not valid JSON, so we can't do better than redacting everything.
+		return []byte(`"` + redactedValue + `"`)
+	}
+
+	redacted, err := json.Marshal(redactTree(tree, secrets, path))
+	if err != nil {
+		return []byte(`"` + redactedValue + `"`)
+	}
+
+	return redacted
+}
+
+func redactTree(v any, secrets secrets, path string) any {
+	switch v := v.(type) {
+	case string:
+		if slices.Contains(secrets.paths, path) {
+			return redactedValue
+		}
+		for _, secret := range secrets.values {
+			if strings.Contains(v, secret) {
+				return redactedValue
+			}
+		}
+		return v
+	case map[string]any:
+		for k, elem := range v {
+			v[k] = redactTree(elem, secrets, joinPath(path, k))
+		}
+		return v
+	case []any:
+		for j, elem := range v {
+			v[j] = redactTree(elem, secrets, fmt.Sprintf("%s[%d]", path, j))
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("TWID_TEST_ADDR", ":8080")
+	t.Setenv("TWID_TEST_TOKEN", "hunter2")
+	writeFile(t, filepath.Join(dir, "secret.txt"), "s3cr3t\n")
+	writeFile(t, filepath.Join(dir, "twid.json"), `{
+		"listen_addr": "${TWID_TEST_ADDR}",
+		"storage": {"path": "$${literal}"},
+		"twisms": {
+			"services": [{
+				"module": "wsbridge_client",
+				"headers": {"Authorization": ["Bearer ${TWID_TEST_TOKEN}"]},
+				"password": "${file:secret.txt}",
+				"bind_addr": ":8080"
+			}]
+		}
+	}`)
+
+	cfg, err := ParseFile(filepath.Join(dir, "twid.json"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, ":8080", cfg.ListenAddr)
+	assert.Equal(t, "${literal}", cfg.Storage.Path)
+
+	var module struct {
+		Headers  map[string][]string `json:"headers"`
+		Password string              `json:"password"`
+	}
+	assert.NoError(t, json.Unmarshal(cfg.Twisms.Services[0].ModuleConfig(), &module))
+	assert.Equal(t, "Bearer hunter2", module.Headers["Authorization"][0])
+	assert.Equal(t, "s3cr3t", module.Password)
+
+	redacted, err := cfg.Twisms.Services[0].MarshalJSON()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(redacted), "hunter2")
+	assert.NotContains(t, string(redacted), "s3cr3t")
+	assert.Contains(t, string(redacted), redactedValue)
+	// Short values are only redacted from the fields that reference them.
+	assert.Contains(t, string(redacted), `":8080"`)
+
+	logged := cfg.LogValue().String()
+	assert.NotContains(t, logged, "hunter2")
+	assert.NotContains(t, logged, "s3cr3t")
+	assert.Contains(t, logged, "wsbridge_client")
+	assert.NotContains(t, logged, `"listen_addr":":8080"`)
+}
+
+func TestParseFile_Unresolved(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "twid.json"), `{
+		"twisms": {"services": [{"module": "x", "token": "${TWID_TEST_DOES_NOT_EXIST}"}]}
+	}`)
+
+	_, err := ParseFile(filepath.Join(dir, "twid.json"))
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "twisms.services[0].token: "), err.Error())
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,18 +3,228 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/twipi/cfgutil"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // Root is the root configuration for the twid package.
 type Root struct {
-	ListenAddr string `json:"listen_addr"`
-	Twisms     Twisms `json:"twisms"`
-	Twicmd     Twicmd `json:"twicmd"`
+	ListenAddr string  `json:"listen_addr"`
+	Storage    Storage `json:"storage"`
+	Twisms     Twisms  `json:"twisms"`
+	Twicmd     Twicmd  `json:"twicmd"`
+	API        API     `json:"api"`
+
+	secrets secrets
+}
+
+// setSecrets sets the secrets to be redacted from the configuration.
+func (r *Root) setSecrets(secrets secrets) {
+	r.secrets = secrets
+	for i := range r.Twisms.Services {
+		path := fmt.Sprintf("twisms.services[%d]", i)
+		r.Twisms.Services[i].redacted = redactJSON(r.Twisms.Services[i].raw, secrets, path)
+	}
+	for i := range r.Twicmd.Parsers {
+		path := fmt.Sprintf("twicmd.parsers[%d]", i)
+		r.Twicmd.Parsers[i].redacted = redactJSON(r.Twicmd.Parsers[i].raw, secrets, path)
+	}
+	for i := range r.Twicmd.Services {
+		path := fmt.Sprintf("twicmd.services[%d]", i)
+		r.Twicmd.Services[i].redacted = redactJSON(r.Twicmd.Services[i].raw, secrets, path)
+	}
+	for i := range r.Twicmd.Pipelines {
+		parsers := r.Twicmd.Pipelines[i].Parsers
+		for j := range parsers {
+			path := fmt.Sprintf("twicmd.pipelines[%d].parsers[%d]", i, j)
+			parsers[j].redacted = redactJSON(parsers[j].raw, secrets, path)
+		}
+	}
+}
+
+// LogValue implements [slog.LogValuer]. The configuration is logged as JSON
+// with all secrets redacted.
+func (r *Root) LogValue() slog.Value {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return slog.StringValue(fmt.Sprintf("<cannot marshal config: %v>", err))
+	}
+	return slog.StringValue(string(redactJSON(b, r.secrets, "")))
+}
+
+// Storage is the configuration for twid's persistent storage.
+type Storage struct {
+	// Path is the directory in which twid keeps its databases. If empty, the
+	// twid directory within the user's configuration directory is used, e.g.
+	// ~/.config/twid on Linux.
+	Path string `json:"path,omitempty"`
+	// InMemory keeps everything in memory instead of in Path, so that it is
+	// lost on restart. It is meant for testing.
+	InMemory bool `json:"in_memory,omitempty"`
+}
+
+// API is the configuration for twid's HTTP API.
+type API struct {
+	// Login is the configuration for logging in using verification codes.
+	Login Login `json:"login"`
+	// Admins is the list of phone numbers that have the admin role. Admins
+	// can view and change all users' control panels and disable, enable and
+	// reload services.
+	Admins []string `json:"admins,omitempty"`
+	// TrustedProxies is the list of IP addresses and CIDR ranges, e.g.
+	// "10.0.0.0/8", of the reverse proxies in front of twid. Requests from
+	// them are attributed to the client in their X-Forwarded-For or X-Real-IP
+	// header, e.g. for the login rate limits. If empty, these headers are
+	// ignored, so every client behind a reverse proxy shares its address.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+	// CORS configures which other websites may call the API from a browser.
+	// By default, none may.
+	CORS CORS `json:"cors,omitempty"`
+	// SecurityHeaders configures the security headers sent with every
+	// response.
+	SecurityHeaders SecurityHeaders `json:"security_headers,omitempty"`
+	// CookieSessions configures logging in using a session cookie as an
+	// alternative to bearer tokens.
+	CookieSessions CookieSessions `json:"cookie_sessions,omitempty"`
+}
+
+// CORS is the configuration for Cross-Origin Resource Sharing.
+type CORS struct {
+	// AllowedOrigins is the list of origins that may call the API, e.g.
+	// "https://example.com". An origin may contain a single "*" wildcard,
+	// and "*" alone allows all origins.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+	// AllowedMethods is the list of methods that the origins may use. It
+	// defaults to GET, POST, PATCH and DELETE.
+	AllowedMethods []string `json:"allowed_methods,omitempty"`
+	// AllowCredentials allows the origins to send cookies. It can't be used
+	// together with allowing all origins.
+	AllowCredentials bool `json:"allow_credentials,omitempty"`
+	// MaxAge is how long browsers may cache the result of a preflight
+	// request.
+	MaxAge cfgutil.Duration `json:"max_age,omitempty"`
+}
+
+// SecurityHeaders is the configuration for the security headers sent with
+// every response of the API and the web UI, such as X-Content-Type-Options
+// and Content-Security-Policy.
+type SecurityHeaders struct {
+	// Disable disables all security headers, e.g. if a reverse proxy already
+	// sets them.
+	Disable bool `json:"disable,omitempty"`
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header. The
+	// header is only sent if this is set, since it must only be used if twid
+	// is served over HTTPS.
+	HSTSMaxAge cfgutil.Duration `json:"hsts_max_age,omitempty"`
+	// ContentSecurityPolicy overrides the default Content-Security-Policy of
+	// the API, which forbids loading anything. The web UI always uses its own
+	// policy.
+	ContentSecurityPolicy string `json:"content_security_policy,omitempty"`
+}
+
+// CookieSessions is the configuration for cookie sessions. Clients opt into
+// them when logging in. Requests authenticated using the cookie must carry
+// the CSRF token returned by the login in the X-CSRF-Token header, unless they
+// only read data.
+type CookieSessions struct {
+	// Enabled allows clients to log in using cookie sessions.
+	Enabled bool `json:"enabled,omitempty"`
+	// Name is the name of the session cookie. It defaults to "twid_session".
+	// The CSRF token is also stored in a cookie with the "_csrf" suffix.
+	Name string `json:"name,omitempty"`
+	// Domain is the domain of the cookies. It defaults to the host that
+	// twid is served on.
+	Domain string `json:"domain,omitempty"`
+	// SameSite is the SameSite attribute of the cookies: "strict", "lax" or
+	// "none". It defaults to "lax".
+	SameSite string `json:"same_site,omitempty"`
+	// Insecure allows the cookies to be sent over plain HTTP. It should only
+	// be used for development.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Login is the configuration for logging in using verification codes sent
+// over SMS. Zero values are replaced with reasonable defaults.
+type Login struct {
+	// IPRateLimit limits how many verification codes a single IP address may
+	// request.
+	IPRateLimit RateLimit `json:"ip_rate_limit,omitempty"`
+	// NumberRateLimit limits how many verification codes may be sent to a
+	// single phone number.
+	NumberRateLimit RateLimit `json:"number_rate_limit,omitempty"`
+	// Cooldown is the minimum time between two verification codes sent to
+	// the same phone number.
+	Cooldown cfgutil.Duration `json:"cooldown,omitempty"`
+	// MaxOutstandingCodes is the maximum number of unexpired verification
+	// codes a phone number may have at once.
+	MaxOutstandingCodes int `json:"max_outstanding_codes,omitempty"`
+	// MaxAttempts is the number of wrong guesses after which all outstanding
+	// verification codes of a phone number are invalidated.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// Countries is the list of ISO 3166-1 alpha-2 country codes, e.g. "US",
+	// whose phone numbers may log in. If empty, all countries are allowed.
+	Countries []string `json:"countries,omitempty"`
+	// Allow is the list of phone numbers that may log in. If empty, everyone
+	// may log in. Admins may always log in unless they are denied. API keys
+	// bound to a phone number are subject to it too.
+	Allow []string `json:"allow,omitempty"`
+	// Deny is the list of phone numbers that may never log in, including
+	// using API keys bound to them. It takes precedence over Allow.
+	Deny []string `json:"deny,omitempty"`
+	// RequireTOTPForSensitive only lets sessions that logged in using TOTP
+	// view and change sensitive options. Phone numbers enroll in TOTP
+	// themselves, so this effectively makes TOTP mandatory for them. API
+	// keys are unaffected.
+	RequireTOTPForSensitive bool `json:"require_totp_for_sensitive,omitempty"`
+}
+
+// RateLimit limits an action to Count times per Period.
+type RateLimit struct {
+	Count  int              `json:"count"`
+	Period cfgutil.Duration `json:"period"`
 }
 
 // Twisms is the configuration for package Twisms.
 type Twisms struct {
 	Services []TwismsService `json:"services"`
+	// Routes is the list of rules for routing outgoing messages to services.
+	// Rules are evaluated in order and the first matching rule is used.
+	// If no rule matches, all services are tried in the order they are
+	// configured.
+	Routes []TwismsRoute `json:"routes,omitempty"`
+	// DebugRoutes serves POST /debug/twisms/route, which explains how a
+	// message would be routed. The endpoint is unauthenticated and reveals
+	// the services and routing rules, so it is disabled by default and should
+	// only be enabled while debugging.
+	DebugRoutes bool `json:"debug_routes,omitempty"`
+}
+
+// TwismsRoute is a rule for routing outgoing messages to Twisms services.
+// A rule matches a message if all of its criteria match. Criteria that are
+// left empty match all messages.
+type TwismsRoute struct {
+	// Name is the name of the rule. It is only used for logging and for
+	// explaining routes.
+	Name string `json:"name,omitempty"`
+	// From is the list of sending phone numbers to match.
+	From []string `json:"from,omitempty"`
+	// ToPrefixes is the list of prefixes of the recipient's phone number to
+	// match, e.g. "+1" or "+4420".
+	ToPrefixes []string `json:"to_prefixes,omitempty"`
+	// ToCountries is the list of ISO 3166-1 alpha-2 country codes of the
+	// recipient's phone number to match, e.g. "US". Countries that share a
+	// calling code cannot be told apart.
+	ToCountries []string `json:"to_countries,omitempty"`
+	// Types is the list of message types to match, e.g. "text".
+	Types []string `json:"types,omitempty"`
+	// Services is the fallback chain of services to send the message through.
+	// Each service is referred to by its name. Services are tried in order
+	// until one succeeds, with unhealthy services tried last.
+	Services []string `json:"services"`
 }
 
 // TwismsService is the configuration for a Twisms service.
@@ -24,12 +234,16 @@ type TwismsService struct {
 	// Module is the name of the Twisms module.
 	// It must be registered with [twid.RegisterTwismsModule].
 	Module string `json:"module"`
+	// Name is the name of the service, which routing rules refer to.
+	// If empty, the module name is used.
+	Name string `json:"name,omitempty"`
 	// HTTPPath is the path that the HTTP handler will be mounted on.
 	// If empty, the service will not get routed, even if it provides an HTTP
 	// handler.
 	HTTPPath string `json:"http_path,omitempty"`
 
-	raw json.RawMessage
+	raw      json.RawMessage
+	redacted json.RawMessage
 }
 
 // UnmarshalJSON implements [json.Unmarshaler].
@@ -43,21 +257,105 @@ func (t *TwismsService) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// MarshalJSON implements [json.Marshaler]. It never fails.
+// MarshalJSON implements [json.Marshaler]. It never fails. Secrets are
+// redacted from the returned JSON, so use [TwismsService.ModuleConfig] to get
+// the configuration to give to the module.
 func (t *TwismsService) MarshalJSON() ([]byte, error) {
-	return t.raw, nil
+	return redactedOr(t.redacted, t.raw), nil
+}
+
+// ModuleConfig returns the full configuration of the module as JSON.
+func (t *TwismsService) ModuleConfig() json.RawMessage {
+	return t.raw
+}
+
+// ServiceName returns the name of the service, which is the module name if
+// no name is set.
+func (t *TwismsService) ServiceName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Module
 }
 
 type Twicmd struct {
 	Parsers  []TwicmdParser  `json:"parsers"`
 	Services []TwicmdService `json:"services"`
+	// Pipelines is the list of command pipelines. Incoming messages go through
+	// the first pipeline whose filters match. Messages that match no pipeline
+	// go through the default pipeline, which uses all parsers and services.
+	Pipelines []TwicmdPipeline `json:"pipelines,omitempty"`
+	// Prompts configures asking for missing command arguments over SMS.
+	Prompts TwicmdPrompts `json:"prompts,omitempty"`
+	// StickyMode configures entering a service by sending only its name, e.g.
+	// "/discord", after which messages go to it until "/exit".
+	StickyMode TwicmdStickyMode `json:"sticky_mode,omitempty"`
+}
+
+// TwicmdPrompts is the configuration for asking for missing command arguments.
+// Instead of failing, twid asks for each missing argument in turn and uses the
+// next reply as its value.
+type TwicmdPrompts struct {
+	// Disable disables asking for missing arguments.
+	Disable bool `json:"disable,omitempty"`
+	// Timeout is how long to wait for each answer. It defaults to 5 minutes.
+	Timeout cfgutil.Duration `json:"timeout,omitempty"`
+	// CancelKeyword is the reply that cancels the command. It defaults to
+	// "cancel".
+	CancelKeyword string `json:"cancel_keyword,omitempty"`
+}
+
+// TwicmdStickyMode is the configuration for entering services.
+type TwicmdStickyMode struct {
+	// Disable disables entering services.
+	Disable bool `json:"disable,omitempty"`
+	// Timeout is how long a conversation may go without messages before the
+	// entered service is left. It defaults to 1 hour.
+	Timeout cfgutil.Duration `json:"timeout,omitempty"`
+}
+
+// TwicmdPipeline is the configuration for a command pipeline. A pipeline
+// allows a single twid to host multiple numbers, each with their own parsers
+// and services.
+type TwicmdPipeline struct {
+	// Name is the name of the pipeline. It is only used for logging.
+	Name string `json:"name"`
+	// Filters selects the incoming messages that go through this pipeline.
+	Filters MessageFilters `json:"filters"`
+	// Parsers is the list of parsers to use for this pipeline.
+	// If empty, the top-level parsers are used.
+	Parsers []TwicmdParser `json:"parsers,omitempty"`
+	// Services is the list of names of the services available to this
+	// pipeline. If empty, all services are available.
+	Services []string `json:"services,omitempty"`
+	// FallbackText is the text to reply with when no parser understands the
+	// message. If empty, a default message is used.
+	FallbackText string `json:"fallback_text,omitempty"`
+}
+
+// MessageFilters is a set of message filters written in Protobuf JSON format,
+// e.g. {"filters": [{"match_to": "+15551234567"}]}.
+type MessageFilters struct {
+	*twismsproto.MessageFilters
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (f *MessageFilters) UnmarshalJSON(b []byte) error {
+	f.MessageFilters = new(twismsproto.MessageFilters)
+	return protojson.Unmarshal(b, f.MessageFilters)
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (f MessageFilters) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(f.MessageFilters)
 }
 
 // TwicmdParser is the configuration for a Twicmd parser.
 type TwicmdParser struct {
 	Module string `json:"module"`
 
-	raw json.RawMessage
+	raw      json.RawMessage
+	redacted json.RawMessage
 }
 
 func (t *TwicmdParser) UnmarshalJSON(b []byte) error {
@@ -70,8 +368,15 @@ func (t *TwicmdParser) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON implements [json.Marshaler]. Secrets are redacted from the
+// returned JSON.
 func (t *TwicmdParser) MarshalJSON() ([]byte, error) {
-	return t.raw, nil
+	return redactedOr(t.redacted, t.raw), nil
+}
+
+// ModuleConfig returns the full configuration of the module as JSON.
+func (t *TwicmdParser) ModuleConfig() json.RawMessage {
+	return t.raw
 }
 
 // TwicmdService is the configuration for a Twicmd service.
@@ -79,7 +384,8 @@ type TwicmdService struct {
 	// Module is the name of the Twicmd module to use for this service.
 	Module string `json:"module"`
 
-	raw json.RawMessage
+	raw      json.RawMessage
+	redacted json.RawMessage
 }
 
 func (t *TwicmdService) UnmarshalJSON(b []byte) error {
@@ -92,6 +398,20 @@ func (t *TwicmdService) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON implements [json.Marshaler]. Secrets are redacted from the
+// returned JSON.
 func (t *TwicmdService) MarshalJSON() ([]byte, error) {
-	return t.raw, nil
+	return redactedOr(t.redacted, t.raw), nil
+}
+
+// ModuleConfig returns the full configuration of the module as JSON.
+func (t *TwicmdService) ModuleConfig() json.RawMessage {
+	return t.raw
+}
+
+func redactedOr(redacted, raw json.RawMessage) json.RawMessage {
+	if redacted != nil {
+		return redacted
+	}
+	return raw
 }
@@ -0,0 +1,154 @@
+package twid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/twipi/pubsub"
+	"github.com/twipi/twipi/internal/catchupstorage/sqlite"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twid/api"
+	"github.com/twipi/twipi/twid/storage"
+	"github.com/twipi/twipi/twisms"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// conversationRetention is how long messages are kept in the
+	// conversation log.
+	conversationRetention = 7 * 24 * time.Hour
+	// conversationBackfillLimit is the maximum number of messages returned by
+	// RecentMessages.
+	conversationBackfillLimit = 100
+)
+
+// conversationLog records all incoming and outgoing messages, so that users
+// can see their conversation with twid.
+type conversationLog struct {
+	storage *sqlite.MessageStorage
+	subs    pubsub.Subscriber[*twismsproto.Message]
+	msgs    chan *twismsproto.Message
+	logger  *slog.Logger
+}
+
+var (
+	_ Starter             = (*conversationLog)(nil)
+	_ api.ConversationLog = (*conversationLog)(nil)
+)
+
+func newConversationLog(ctx context.Context, storage *storage.Storage, logger *slog.Logger) (*conversationLog, error) {
+	db, err := storage.OpenSQLite(ctx, "conversations", sqlite.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversationLog{
+		storage: sqlite.NewMessageStorageFromDB(db, logger),
+		msgs:    make(chan *twismsproto.Message),
+		logger:  logger,
+	}, nil
+}
+
+// Start implements [Starter].
+func (l *conversationLog) Start(ctx context.Context) error {
+	errg, ctx := errgroup.WithContext(ctx)
+
+	errg.Go(func() error {
+		return l.subs.Listen(ctx, l.msgs)
+	})
+
+	errg.Go(func() error {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			n, err := l.storage.DeleteMessagesBefore(ctx, time.Now().Add(-conversationRetention))
+			if err != nil && ctx.Err() == nil {
+				l.logger.Error(
+					"failed to delete old messages",
+					"err", err)
+			}
+			if n > 0 {
+				l.logger.Debug(
+					"deleted old messages",
+					"count", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	})
+
+	return errg.Wait()
+}
+
+// Close closes the underlying storage.
+func (l *conversationLog) Close() error {
+	return l.storage.Close()
+}
+
+// record stores the message and publishes it to all subscribers.
+func (l *conversationLog) record(ctx context.Context, msg *twismsproto.Message) {
+	if msg.Timestamp == nil {
+		msg = proto.Clone(msg).(*twismsproto.Message)
+		msg.Timestamp = timestamppb.Now()
+	}
+
+	if err := l.storage.StoreMessage(ctx, msg); err != nil {
+		l.logger.Error(
+			"failed to record message",
+			"from", msg.From,
+			"to", msg.To,
+			"err", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case l.msgs <- msg:
+	}
+}
+
+// SubscribeMessages implements [twisms.MessageSubscriber]. Unlike the Twisms
+// services, both incoming and outgoing messages are published.
+func (l *conversationLog) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
+	l.subs.Subscribe(ch, func(msg *twismsproto.Message) bool {
+		return twisms.FilterMessage(filters, msg)
+	})
+}
+
+// UnsubscribeMessages implements [twisms.MessageSubscriber].
+func (l *conversationLog) UnsubscribeMessages(ch chan<- *twismsproto.Message) {
+	l.subs.Unsubscribe(ch)
+}
+
+// RecentMessages implements [api.ConversationLog].
+func (l *conversationLog) RecentMessages(ctx context.Context, phoneNumber string, since time.Time) ([]*twismsproto.Message, error) {
+	var msgs []*twismsproto.Message
+	var err error
+
+	iter := l.storage.RetrieveMessages(ctx, since, []string{phoneNumber})
+	iter(func(msg *twismsproto.Message, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		msgs = append(msgs, msg)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve messages: %w", err)
+	}
+
+	if len(msgs) > conversationBackfillLimit {
+		msgs = msgs[len(msgs)-conversationBackfillLimit:]
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,9 @@
+-- name: DefaultService :one
+SELECT service FROM default_services WHERE phone_number = ?;
+
+-- name: UpsertDefaultService :exec
+INSERT INTO default_services (phone_number, service) VALUES (?, ?)
+ON CONFLICT (phone_number) DO UPDATE SET service = excluded.service;
+
+-- name: DeleteDefaultService :exec
+DELETE FROM default_services WHERE phone_number = ?;
@@ -0,0 +1,4 @@
+CREATE TABLE default_services (
+	phone_number TEXT PRIMARY KEY,
+	service TEXT NOT NULL
+);
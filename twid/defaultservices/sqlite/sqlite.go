@@ -0,0 +1,71 @@
+// Package sqlite implements a SQLite storage backend for the default services
+// of users.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	_ "embed"
+
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/defaultservices/sqlite/queries"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+//go:embed schema.sql
+var schema string
+
+// DefaultServiceStore is the SQLite storage backend for default services.
+type DefaultServiceStore struct {
+	db     *sql.DB
+	q      *queries.Queries
+	logger *slog.Logger
+}
+
+var _ twicmd.DefaultServiceStore = (*DefaultServiceStore)(nil)
+
+// NewDefaultServiceStore creates a new SQLite default service store within
+// the given storage.
+func NewDefaultServiceStore(ctx context.Context, storage *storage.Storage, logger *slog.Logger) (*DefaultServiceStore, error) {
+	db, err := storage.OpenSQLite(ctx, "default_services", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DefaultServiceStore{
+		db:     db,
+		q:      queries.New(db),
+		logger: logger,
+	}, nil
+}
+
+// Close closes the database.
+func (s *DefaultServiceStore) Close() error {
+	return s.db.Close()
+}
+
+// DefaultService implements [twicmd.DefaultServiceStore].
+func (s *DefaultServiceStore) DefaultService(ctx context.Context, phoneNumber string) (string, error) {
+	service, err := s.q.DefaultService(ctx, phoneNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return service, nil
+}
+
+// SetDefaultService implements [twicmd.DefaultServiceStore].
+func (s *DefaultServiceStore) SetDefaultService(ctx context.Context, phoneNumber, service string) error {
+	if service == "" {
+		return s.q.DeleteDefaultService(ctx, phoneNumber)
+	}
+	return s.q.UpsertDefaultService(ctx, queries.UpsertDefaultServiceParams{
+		PhoneNumber: phoneNumber,
+		Service:     service,
+	})
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+func TestDefaultServiceStore(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	storage := storage.New(config.Storage{Path: t.TempDir()}, logger)
+
+	store, err := NewDefaultServiceStore(ctx, storage, logger)
+	assert.NoError(t, err)
+
+	const number = "+15555550123"
+	get := func() string {
+		service, err := store.DefaultService(ctx, number)
+		assert.NoError(t, err)
+		return service
+	}
+
+	assert.Equal(t, "", get())
+	assert.NoError(t, store.SetDefaultService(ctx, number, "weather"))
+	assert.NoError(t, store.SetDefaultService(ctx, number, "discord"))
+	assert.Equal(t, "discord", get())
+	assert.NoError(t, store.SetDefaultService(ctx, number, ""))
+	assert.Equal(t, "", get())
+}
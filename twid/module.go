@@ -0,0 +1,39 @@
+package twid
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/storage"
+	"github.com/twipi/twipi/twisms"
+)
+
+// ModuleContext is given to the constructor of every module. It gives the
+// module access to the facilities shared across the twid daemon.
+//
+// Modules are constructed one after another, so the shared facilities may not
+// be fully populated until every module is created. For example, SMS will only
+// route to all Twisms services and Services will only contain all Twicmd
+// services once twid starts. Modules should therefore only hold onto these
+// facilities in their constructor and use them once started.
+type ModuleContext struct {
+	// SMS is the composite message service of all configured Twisms services.
+	// Messages sent through it are routed to the appropriate service.
+	SMS twisms.MessageService
+	// Services is the lookup of all configured Twicmd services.
+	Services *twicmd.ServiceLookup
+	// Storage is the persistent storage shared by twid and all modules.
+	Storage *storage.Storage
+	// Metrics is the Prometheus registerer that the module should register
+	// its metrics with.
+	Metrics prometheus.Registerer
+	// Logger is the logger for the module.
+	Logger *slog.Logger
+}
+
+// withLogger returns a copy of the context with the given logger.
+func (m ModuleContext) withLogger(logger *slog.Logger) ModuleContext {
+	m.Logger = logger
+	return m
+}
@@ -0,0 +1,110 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope is a permission that can be granted to an API key.
+type Scope string
+
+const (
+	// ScopeSendMessages allows sending messages. If the API key is bound to a
+	// phone number, messages may only be sent to that number.
+	ScopeSendMessages Scope = "messages:send"
+	// ScopeReadServices allows reading the control panel values of the phone
+	// number that the API key is bound to, without changing them.
+	// [ScopeManageConfig] implies it.
+	ScopeReadServices Scope = "services:read"
+	// ScopeManageConfig allows reading and changing the control panel values
+	// of the phone number that the API key is bound to.
+	ScopeManageConfig Scope = "config:manage"
+	// ScopeAdmin grants the admin role.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes is the list of all known scopes.
+var Scopes = []Scope{
+	ScopeSendMessages,
+	ScopeReadServices,
+	ScopeManageConfig,
+	ScopeAdmin,
+}
+
+// ParseScope parses a scope, returning an error if the scope is unknown.
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(s)
+	if !slices.Contains(Scopes, scope) {
+		return "", fmt.Errorf("unknown scope %q", s)
+	}
+	return scope, nil
+}
+
+// APIKeyPrefix is the prefix of all API key tokens. It distinguishes API keys
+// from session tokens.
+const APIKeyPrefix = "twk_"
+
+// IsAPIKeyToken returns true if the token is an API key token.
+func IsAPIKeyToken(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// NewAPIKeyToken generates a new random API key token. The token is only ever
+// shown once; only its [TokenID] is stored.
+func NewAPIKeyToken() (string, error) {
+	var r [32]byte
+	if _, err := rand.Read(r[:]); err != nil {
+		return "", fmt.Errorf("could not generate API key: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(r[:]), nil
+}
+
+// APIKey is a long-lived key for machine-to-machine access.
+type APIKey struct {
+	// ID identifies the API key. It is derived from the token using
+	// [TokenID], so the token itself is never stored.
+	ID string
+	// Name is the unique name of the API key.
+	Name   string
+	Scopes []Scope
+	// PhoneNumber is the phone number that the API key is bound to, if any.
+	PhoneNumber string
+	CreatedAt   time.Time
+	// LastUsedAt is the last time the API key was used. It is zero if the
+	// key was never used.
+	LastUsedAt time.Time
+}
+
+// HasScope returns true if the API key was granted the given scope, or a
+// scope that implies it.
+func (k APIKey) HasScope(scope Scope) bool {
+	if scope == ScopeReadServices && slices.Contains(k.Scopes, ScopeManageConfig) {
+		return true
+	}
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Validate returns an error if the API key is not valid.
+func (k APIKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("API key has no name")
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("API key has no scopes")
+	}
+	for _, scope := range k.Scopes {
+		if _, err := ParseScope(string(scope)); err != nil {
+			return err
+		}
+	}
+	for _, scope := range []Scope{ScopeReadServices, ScopeManageConfig} {
+		if slices.Contains(k.Scopes, scope) && k.PhoneNumber == "" {
+			return fmt.Errorf("scope %q requires a phone number", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package sessions
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	read := APIKey{Name: "read", Scopes: []Scope{ScopeReadServices}, PhoneNumber: "+15555550123"}
+	assert.NoError(t, read.Validate())
+	assert.True(t, read.HasScope(ScopeReadServices))
+	assert.False(t, read.HasScope(ScopeManageConfig))
+
+	manage := APIKey{Name: "manage", Scopes: []Scope{ScopeManageConfig}, PhoneNumber: "+15555550123"}
+	assert.NoError(t, manage.Validate())
+	assert.True(t, manage.HasScope(ScopeReadServices), "config:manage must imply services:read")
+
+	unbound := APIKey{Name: "unbound", Scopes: []Scope{ScopeReadServices}}
+	assert.Error(t, unbound.Validate())
+
+	unknown := APIKey{Name: "unknown", Scopes: []Scope{"services:write"}}
+	assert.Error(t, unknown.Validate())
+}
@@ -0,0 +1,181 @@
+// Package sessions provides persistent storage for login codes, sessions, API
+// keys and TOTP second factors.
+package sessions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a code or session does not exist or has
+	// expired.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when a code or session already exists.
+	ErrExists = errors.New("already exists")
+	// ErrUsed is returned when a one-time code has already been used.
+	ErrUsed = errors.New("already used")
+)
+
+// Code is a pending login code that was sent to a phone number.
+type Code struct {
+	Code        string
+	PhoneNumber string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	// Attempts is the number of wrong guesses made against the phone number
+	// since the code was created.
+	Attempts int
+}
+
+// Session is a logged in session.
+type Session struct {
+	// ID identifies the session. It is derived from the session token using
+	// [TokenID], so the token itself is never stored.
+	ID          string
+	PhoneNumber string
+	UserAgent   string
+	CreatedAt   time.Time
+	LastUsedAt  time.Time
+	ExpiresAt   time.Time
+	// SecondFactor is true if the session was logged into using a TOTP code
+	// or a recovery code in addition to the login code.
+	SecondFactor bool
+}
+
+// Expired returns true if the session has expired.
+func (s Session) Expired() bool {
+	return !s.ExpiresAt.After(time.Now())
+}
+
+// TokenID returns the session ID of the given session token.
+func TokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Store persists login codes, sessions, API keys and TOTP second factors.
+type Store interface {
+	io.Closer
+
+	// CreateCode stores a new pending login code. It returns [ErrExists] if
+	// the code is already in use.
+	CreateCode(ctx context.Context, code Code) error
+	// Codes returns the unexpired login codes of the given phone number,
+	// newest first.
+	Codes(ctx context.Context, phoneNumber string) ([]Code, error)
+	// AddCodeAttempt records a wrong guess against all unexpired login codes
+	// of the given phone number.
+	AddCodeAttempt(ctx context.Context, phoneNumber string) error
+	// UseCode atomically deletes the unexpired login code of the given phone
+	// number if it has had fewer than maxAttempts wrong guesses, and returns
+	// it. It returns [ErrUsed] if there is no such code, including if it was
+	// used concurrently.
+	UseCode(ctx context.Context, phoneNumber, code string, maxAttempts int) (Code, error)
+	// DeleteCode deletes the pending login code.
+	DeleteCode(ctx context.Context, code string) error
+
+	// CreateSession stores a new session. It returns [ErrExists] if the
+	// session ID is already in use.
+	CreateSession(ctx context.Context, session Session) error
+	// Session returns the session with the given ID. It returns [ErrNotFound]
+	// if the session does not exist or has expired.
+	Session(ctx context.Context, id string) (Session, error)
+	// Sessions returns the unexpired sessions of the given phone number, most
+	// recently used first.
+	Sessions(ctx context.Context, phoneNumber string) ([]Session, error)
+	// TouchSession records a use of the session and extends its expiry.
+	TouchSession(ctx context.Context, id string, lastUsedAt, expiresAt time.Time) error
+	// DeleteSession deletes the session with the given ID.
+	DeleteSession(ctx context.Context, id string) error
+	// DeleteOtherSessions deletes all sessions of the given phone number
+	// except for the session with the given ID.
+	DeleteOtherSessions(ctx context.Context, phoneNumber, id string) error
+
+	// CreateAPIKey stores a new API key. It returns [ErrExists] if an API key
+	// with the same name already exists.
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	// APIKey returns the API key with the given ID. It returns [ErrNotFound]
+	// if the API key does not exist.
+	APIKey(ctx context.Context, id string) (APIKey, error)
+	// APIKeys returns all API keys sorted by name.
+	APIKeys(ctx context.Context) ([]APIKey, error)
+	// TouchAPIKey records a use of the API key.
+	TouchAPIKey(ctx context.Context, id string, lastUsedAt time.Time) error
+	// DeleteAPIKey deletes the API key with the given name. It returns
+	// [ErrNotFound] if the API key does not exist.
+	DeleteAPIKey(ctx context.Context, name string) error
+
+	// CreateTOTP stores a new unconfirmed TOTP enrolment, replacing any
+	// other unconfirmed enrolment of the phone number. It returns [ErrExists]
+	// if the phone number already has a confirmed enrolment.
+	CreateTOTP(ctx context.Context, totp TOTP) error
+	// TOTP returns the TOTP enrolment of the given phone number. It returns
+	// [ErrNotFound] if the phone number isn't enrolled.
+	TOTP(ctx context.Context, phoneNumber string) (TOTP, error)
+	// ConfirmTOTP confirms the TOTP enrolment of the given phone number and
+	// replaces its recovery codes with the given code IDs.
+	ConfirmTOTP(ctx context.Context, phoneNumber string, recoveryCodeIDs []string) error
+	// UseTOTPStep records that a code of the given time step was accepted. It
+	// returns [ErrUsed] if a code of the same or a later step was already
+	// accepted.
+	UseTOTPStep(ctx context.Context, phoneNumber string, step int64) error
+	// UseRecoveryCode deletes the recovery code with the given ID. It returns
+	// [ErrNotFound] if the phone number has no such recovery code.
+	UseRecoveryCode(ctx context.Context, phoneNumber, id string) error
+	// SetRecoveryCodes replaces the recovery codes of the given phone number
+	// with the given code IDs.
+	SetRecoveryCodes(ctx context.Context, phoneNumber string, ids []string) error
+	// RecoveryCodesLeft returns the number of unused recovery codes of the
+	// given phone number.
+	RecoveryCodesLeft(ctx context.Context, phoneNumber string) (int, error)
+	// DeleteTOTP deletes the TOTP enrolment and the recovery codes of the
+	// given phone number. It returns [ErrNotFound] if the phone number isn't
+	// enrolled.
+	DeleteTOTP(ctx context.Context, phoneNumber string) error
+
+	// DeleteExpired deletes all codes and sessions that expired before the
+	// given time.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Sweeper periodically deletes expired codes and sessions from a [Store].
+type Sweeper struct {
+	store    Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewSweeper creates a new Sweeper that sweeps the store every interval.
+func NewSweeper(store Store, interval time.Duration, logger *slog.Logger) *Sweeper {
+	return &Sweeper{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start starts the sweeper. It runs until the context is canceled.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.store.DeleteExpired(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			s.logger.Error(
+				"failed to delete expired sessions",
+				"err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+-- name: InsertLoginCode :exec
+INSERT INTO login_codes (code, phone_number, created_at, expires_at) VALUES (?, ?, ?, ?);
+
+-- name: LoginCodesForNumber :many
+SELECT * FROM login_codes WHERE phone_number = ? AND expires_at > ? ORDER BY created_at DESC;
+
+-- name: IncrementLoginCodeAttempts :exec
+UPDATE login_codes SET attempts = attempts + 1 WHERE phone_number = ? AND expires_at > ?;
+
+-- name: DeleteLoginCode :exec
+DELETE FROM login_codes WHERE code = ?;
+
+-- name: UseLoginCode :one
+DELETE FROM login_codes WHERE code = ? AND phone_number = ? AND expires_at > ? AND attempts < ? RETURNING *;
+
+-- name: DeleteExpiredLoginCodes :execrows
+DELETE FROM login_codes WHERE expires_at <= ?;
+
+-- name: InsertSession :exec
+INSERT INTO sessions (id, phone_number, user_agent, created_at, last_used_at, expires_at, second_factor) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: Session :one
+SELECT * FROM sessions WHERE id = ? AND expires_at > ?;
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_used_at = ?, expires_at = ? WHERE id = ?;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = ?;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at <= ?;
+
+-- name: SessionsForNumber :many
+SELECT * FROM sessions WHERE phone_number = ? AND expires_at > ? ORDER BY last_used_at DESC;
+
+-- name: DeleteSessionsExcept :exec
+DELETE FROM sessions WHERE phone_number = ? AND id != ?;
+
+-- name: InsertAPIKey :exec
+INSERT INTO api_keys (id, name, scopes, phone_number, created_at, last_used_at) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: APIKey :one
+SELECT * FROM api_keys WHERE id = ?;
+
+-- name: APIKeys :many
+SELECT * FROM api_keys ORDER BY name ASC;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = ? WHERE id = ?;
+
+-- name: DeleteAPIKeyByName :execrows
+DELETE FROM api_keys WHERE name = ?;
+
+-- name: UpsertPendingTOTP :execrows
+INSERT INTO totp (phone_number, secret, confirmed, created_at, last_step) VALUES (?, ?, FALSE, ?, 0)
+ON CONFLICT (phone_number) DO UPDATE SET secret = excluded.secret, created_at = excluded.created_at, last_step = 0
+WHERE NOT totp.confirmed;
+
+-- name: TOTP :one
+SELECT * FROM totp WHERE phone_number = ?;
+
+-- name: ConfirmTOTP :execrows
+UPDATE totp SET confirmed = TRUE WHERE phone_number = ?;
+
+-- name: UseTOTPStep :execrows
+UPDATE totp SET last_step = @step WHERE phone_number = @phone_number AND last_step < @step;
+
+-- name: DeleteTOTP :execrows
+DELETE FROM totp WHERE phone_number = ?;
+
+-- name: InsertRecoveryCode :exec
+INSERT INTO totp_recovery_codes (phone_number, id) VALUES (?, ?);
+
+-- name: DeleteRecoveryCode :execrows
+DELETE FROM totp_recovery_codes WHERE phone_number = ? AND id = ?;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM totp_recovery_codes WHERE phone_number = ?;
+
+-- name: CountRecoveryCodes :one
+SELECT COUNT(*) FROM totp_recovery_codes WHERE phone_number = ?;
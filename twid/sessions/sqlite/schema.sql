@@ -0,0 +1,54 @@
+CREATE TABLE login_codes (
+	code TEXT PRIMARY KEY,
+	phone_number TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX login_codes_phone_number_idx ON login_codes(phone_number);
+CREATE INDEX login_codes_expires_at_idx ON login_codes(expires_at);
+
+CREATE TABLE sessions (
+	id TEXT PRIMARY KEY,
+	phone_number TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX sessions_phone_number_idx ON sessions(phone_number);
+CREATE INDEX sessions_expires_at_idx ON sessions(expires_at);
+
+--------------------------------- NEW VERSION ---------------------------------
+
+ALTER TABLE login_codes ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+--------------------------------- NEW VERSION ---------------------------------
+
+CREATE TABLE api_keys (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL,
+	phone_number TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER NOT NULL
+);
+
+--------------------------------- NEW VERSION ---------------------------------
+
+ALTER TABLE sessions ADD COLUMN second_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE totp (
+	phone_number TEXT PRIMARY KEY,
+	secret BLOB NOT NULL,
+	confirmed BOOLEAN NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_step INTEGER NOT NULL
+);
+
+CREATE TABLE totp_recovery_codes (
+	phone_number TEXT NOT NULL,
+	id TEXT NOT NULL,
+	PRIMARY KEY (phone_number, id)
+);
@@ -0,0 +1,468 @@
+// Package sqlite implements a SQLite storage backend for sessions.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	"github.com/twipi/twipi/twid/sessions"
+	"github.com/twipi/twipi/twid/sessions/sqlite/queries"
+	"github.com/twipi/twipi/twid/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed schema.sql
+var schema string
+
+// SessionStore is the SQLite storage backend for sessions.
+type SessionStore struct {
+	db     *sql.DB
+	q      *queries.Queries
+	logger *slog.Logger
+}
+
+var _ sessions.Store = (*SessionStore)(nil)
+
+// NewSessionStore creates a new SQLite session store within the given storage.
+func NewSessionStore(ctx context.Context, storage *storage.Storage, logger *slog.Logger) (*SessionStore, error) {
+	db, err := storage.OpenSQLite(ctx, "sessions", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionStore{
+		db:     db,
+		q:      queries.New(db),
+		logger: logger,
+	}, nil
+}
+
+// Close closes the database.
+func (s *SessionStore) Close() error {
+	return s.db.Close()
+}
+
+// CreateCode implements [sessions.Store].
+func (s *SessionStore) CreateCode(ctx context.Context, code sessions.Code) error {
+	err := s.q.InsertLoginCode(ctx, queries.InsertLoginCodeParams{
+		Code:        code.Code,
+		PhoneNumber: code.PhoneNumber,
+		CreatedAt:   code.CreatedAt.Unix(),
+		ExpiresAt:   code.ExpiresAt.Unix(),
+	})
+	return convertError(err)
+}
+
+// Codes implements [sessions.Store].
+func (s *SessionStore) Codes(ctx context.Context, phoneNumber string) ([]sessions.Code, error) {
+	rows, err := s.q.LoginCodesForNumber(ctx, queries.LoginCodesForNumberParams{
+		PhoneNumber: phoneNumber,
+		ExpiresAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]sessions.Code, len(rows))
+	for i, row := range rows {
+		codes[i] = sessions.Code{
+			Code:        row.Code,
+			PhoneNumber: row.PhoneNumber,
+			CreatedAt:   time.Unix(row.CreatedAt, 0),
+			ExpiresAt:   time.Unix(row.ExpiresAt, 0),
+			Attempts:    int(row.Attempts),
+		}
+	}
+
+	return codes, nil
+}
+
+// AddCodeAttempt implements [sessions.Store].
+func (s *SessionStore) AddCodeAttempt(ctx context.Context, phoneNumber string) error {
+	return s.q.IncrementLoginCodeAttempts(ctx, queries.IncrementLoginCodeAttemptsParams{
+		PhoneNumber: phoneNumber,
+		ExpiresAt:   time.Now().Unix(),
+	})
+}
+
+// UseCode implements [sessions.Store].
+func (s *SessionStore) UseCode(ctx context.Context, phoneNumber, code string, maxAttempts int) (sessions.Code, error) {
+	row, err := s.q.UseLoginCode(ctx, queries.UseLoginCodeParams{
+		Code:        code,
+		PhoneNumber: phoneNumber,
+		ExpiresAt:   time.Now().Unix(),
+		Attempts:    int64(maxAttempts),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sessions.Code{}, sessions.ErrUsed
+		}
+		return sessions.Code{}, err
+	}
+	return sessions.Code{
+		Code:        row.Code,
+		PhoneNumber: row.PhoneNumber,
+		CreatedAt:   time.Unix(row.CreatedAt, 0),
+		ExpiresAt:   time.Unix(row.ExpiresAt, 0),
+		Attempts:    int(row.Attempts),
+	}, nil
+}
+
+// DeleteCode implements [sessions.Store].
+func (s *SessionStore) DeleteCode(ctx context.Context, code string) error {
+	return s.q.DeleteLoginCode(ctx, code)
+}
+
+// CreateSession implements [sessions.Store].
+func (s *SessionStore) CreateSession(ctx context.Context, session sessions.Session) error {
+	err := s.q.InsertSession(ctx, queries.InsertSessionParams{
+		ID:           session.ID,
+		PhoneNumber:  session.PhoneNumber,
+		UserAgent:    session.UserAgent,
+		CreatedAt:    session.CreatedAt.Unix(),
+		LastUsedAt:   session.LastUsedAt.Unix(),
+		ExpiresAt:    session.ExpiresAt.Unix(),
+		SecondFactor: session.SecondFactor,
+	})
+	return convertError(err)
+}
+
+// Session implements [sessions.Store].
+func (s *SessionStore) Session(ctx context.Context, id string) (sessions.Session, error) {
+	row, err := s.q.Session(ctx, queries.SessionParams{
+		ID:        id,
+		ExpiresAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return sessions.Session{}, convertError(err)
+	}
+	return convertSession(row), nil
+}
+
+// Sessions implements [sessions.Store].
+func (s *SessionStore) Sessions(ctx context.Context, phoneNumber string) ([]sessions.Session, error) {
+	rows, err := s.q.SessionsForNumber(ctx, queries.SessionsForNumberParams{
+		PhoneNumber: phoneNumber,
+		ExpiresAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]sessions.Session, len(rows))
+	for i, row := range rows {
+		list[i] = convertSession(row)
+	}
+
+	return list, nil
+}
+
+// TouchSession implements [sessions.Store].
+func (s *SessionStore) TouchSession(ctx context.Context, id string, lastUsedAt, expiresAt time.Time) error {
+	return s.q.TouchSession(ctx, queries.TouchSessionParams{
+		ID:         id,
+		LastUsedAt: lastUsedAt.Unix(),
+		ExpiresAt:  expiresAt.Unix(),
+	})
+}
+
+// DeleteSession implements [sessions.Store].
+func (s *SessionStore) DeleteSession(ctx context.Context, id string) error {
+	return s.q.DeleteSession(ctx, id)
+}
+
+// DeleteOtherSessions implements [sessions.Store].
+func (s *SessionStore) DeleteOtherSessions(ctx context.Context, phoneNumber, id string) error {
+	return s.q.DeleteSessionsExcept(ctx, queries.DeleteSessionsExceptParams{
+		PhoneNumber: phoneNumber,
+		ID:          id,
+	})
+}
+
+// CreateAPIKey implements [sessions.Store].
+func (s *SessionStore) CreateAPIKey(ctx context.Context, key sessions.APIKey) error {
+	err := s.q.InsertAPIKey(ctx, queries.InsertAPIKeyParams{
+		ID:          key.ID,
+		Name:        key.Name,
+		Scopes:      joinScopes(key.Scopes),
+		PhoneNumber: key.PhoneNumber,
+		CreatedAt:   key.CreatedAt.Unix(),
+		LastUsedAt:  unixOrZero(key.LastUsedAt),
+	})
+	return convertError(err)
+}
+
+// APIKey implements [sessions.Store].
+func (s *SessionStore) APIKey(ctx context.Context, id string) (sessions.APIKey, error) {
+	row, err := s.q.APIKey(ctx, id)
+	if err != nil {
+		return sessions.APIKey{}, convertError(err)
+	}
+	return convertAPIKey(row), nil
+}
+
+// APIKeys implements [sessions.Store].
+func (s *SessionStore) APIKeys(ctx context.Context) ([]sessions.APIKey, error) {
+	rows, err := s.q.APIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]sessions.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = convertAPIKey(row)
+	}
+
+	return keys, nil
+}
+
+// TouchAPIKey implements [sessions.Store].
+func (s *SessionStore) TouchAPIKey(ctx context.Context, id string, lastUsedAt time.Time) error {
+	return s.q.TouchAPIKey(ctx, queries.TouchAPIKeyParams{
+		ID:         id,
+		LastUsedAt: lastUsedAt.Unix(),
+	})
+}
+
+// DeleteAPIKey implements [sessions.Store].
+func (s *SessionStore) DeleteAPIKey(ctx context.Context, name string) error {
+	n, err := s.q.DeleteAPIKeyByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sessions.ErrNotFound
+	}
+	return nil
+}
+
+// CreateTOTP implements [sessions.Store].
+func (s *SessionStore) CreateTOTP(ctx context.Context, totp sessions.TOTP) error {
+	n, err := s.q.UpsertPendingTOTP(ctx, queries.UpsertPendingTOTPParams{
+		PhoneNumber: totp.PhoneNumber,
+		Secret:      totp.Secret,
+		CreatedAt:   totp.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// The existing enrolment is confirmed, so it wasn't replaced.
+		return sessions.ErrExists
+	}
+	return nil
+}
+
+// TOTP implements [sessions.Store].
+func (s *SessionStore) TOTP(ctx context.Context, phoneNumber string) (sessions.TOTP, error) {
+	row, err := s.q.TOTP(ctx, phoneNumber)
+	if err != nil {
+		return sessions.TOTP{}, convertError(err)
+	}
+	return sessions.TOTP{
+		PhoneNumber: row.PhoneNumber,
+		Secret:      row.Secret,
+		Confirmed:   row.Confirmed,
+		CreatedAt:   time.Unix(row.CreatedAt, 0),
+		LastStep:    row.LastStep,
+	}, nil
+}
+
+// ConfirmTOTP implements [sessions.Store].
+func (s *SessionStore) ConfirmTOTP(ctx context.Context, phoneNumber string, recoveryCodeIDs []string) error {
+	return s.tx(ctx, func(q *queries.Queries) error {
+		n, err := q.ConfirmTOTP(ctx, phoneNumber)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sessions.ErrNotFound
+		}
+		return setRecoveryCodes(ctx, q, phoneNumber, recoveryCodeIDs)
+	})
+}
+
+// UseTOTPStep implements [sessions.Store].
+func (s *SessionStore) UseTOTPStep(ctx context.Context, phoneNumber string, step int64) error {
+	n, err := s.q.UseTOTPStep(ctx, queries.UseTOTPStepParams{
+		Step:        step,
+		PhoneNumber: phoneNumber,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sessions.ErrUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode implements [sessions.Store].
+func (s *SessionStore) UseRecoveryCode(ctx context.Context, phoneNumber, id string) error {
+	n, err := s.q.DeleteRecoveryCode(ctx, queries.DeleteRecoveryCodeParams{
+		PhoneNumber: phoneNumber,
+		ID:          id,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sessions.ErrNotFound
+	}
+	return nil
+}
+
+// SetRecoveryCodes implements [sessions.Store].
+func (s *SessionStore) SetRecoveryCodes(ctx context.Context, phoneNumber string, ids []string) error {
+	return s.tx(ctx, func(q *queries.Queries) error {
+		return setRecoveryCodes(ctx, q, phoneNumber, ids)
+	})
+}
+
+func setRecoveryCodes(ctx context.Context, q *queries.Queries, phoneNumber string, ids []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, phoneNumber); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err := q.InsertRecoveryCode(ctx, queries.InsertRecoveryCodeParams{
+			PhoneNumber: phoneNumber,
+			ID:          id,
+		})
+		if err != nil {
+			return convertError(err)
+		}
+	}
+	return nil
+}
+
+// RecoveryCodesLeft implements [sessions.Store].
+func (s *SessionStore) RecoveryCodesLeft(ctx context.Context, phoneNumber string) (int, error) {
+	n, err := s.q.CountRecoveryCodes(ctx, phoneNumber)
+	return int(n), err
+}
+
+// DeleteTOTP implements [sessions.Store].
+func (s *SessionStore) DeleteTOTP(ctx context.Context, phoneNumber string) error {
+	return s.tx(ctx, func(q *queries.Queries) error {
+		n, err := q.DeleteTOTP(ctx, phoneNumber)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sessions.ErrNotFound
+		}
+		return q.DeleteRecoveryCodes(ctx, phoneNumber)
+	})
+}
+
+// tx runs f in a transaction, which is committed if f returns nil.
+func (s *SessionStore) tx(ctx context.Context, f func(q *queries.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := f(s.q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExpired implements [sessions.Store].
+func (s *SessionStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	codes, err := s.q.DeleteExpiredLoginCodes(ctx, now.Unix())
+	if err != nil {
+		return fmt.Errorf("could not delete expired codes: %w", err)
+	}
+
+	sessions, err := s.q.DeleteExpiredSessions(ctx, now.Unix())
+	if err != nil {
+		return fmt.Errorf("could not delete expired sessions: %w", err)
+	}
+
+	if codes > 0 || sessions > 0 {
+		s.logger.Debug(
+			"deleted expired codes and sessions",
+			"codes", codes,
+			"sessions", sessions)
+	}
+
+	return nil
+}
+
+func convertSession(row queries.Session) sessions.Session {
+	return sessions.Session{
+		ID:           row.ID,
+		PhoneNumber:  row.PhoneNumber,
+		UserAgent:    row.UserAgent,
+		CreatedAt:    time.Unix(row.CreatedAt, 0),
+		LastUsedAt:   time.Unix(row.LastUsedAt, 0),
+		ExpiresAt:    time.Unix(row.ExpiresAt, 0),
+		SecondFactor: row.SecondFactor,
+	}
+}
+
+func convertAPIKey(row queries.ApiKey) sessions.APIKey {
+	key := sessions.APIKey{
+		ID:          row.ID,
+		Name:        row.Name,
+		Scopes:      splitScopes(row.Scopes),
+		PhoneNumber: row.PhoneNumber,
+		CreatedAt:   time.Unix(row.CreatedAt, 0),
+	}
+	if row.LastUsedAt != 0 {
+		key.LastUsedAt = time.Unix(row.LastUsedAt, 0)
+	}
+	return key
+}
+
+// joinScopes joins scopes into the space-separated list that is stored in the
+// database.
+func joinScopes(scopes []sessions.Scope) string {
+	strs := make([]string, len(scopes))
+	for i, scope := range scopes {
+		strs[i] = string(scope)
+	}
+	return strings.Join(strs, " ")
+}
+
+func splitScopes(s string) []sessions.Scope {
+	fields := strings.Fields(s)
+	scopes := make([]sessions.Scope, len(fields))
+	for i, field := range fields {
+		scopes[i] = sessions.Scope(field)
+	}
+	return scopes
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func convertError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return sessions.ErrNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return sessions.ErrExists
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twid/sessions"
+	"github.com/twipi/twipi/twid/storage"
+)
+
+func TestSessionStore(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	storage := storage.New(config.Storage{Path: t.TempDir()}, logger)
+
+	store, err := NewSessionStore(ctx, storage, logger)
+	assert.NoError(t, err)
+
+	now := time.Unix(time.Now().Unix(), 0)
+	session := sessions.Session{
+		ID:           sessions.TokenID("token"),
+		PhoneNumber:  "+15555550123",
+		UserAgent:    "test",
+		CreatedAt:    now,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(time.Hour),
+		SecondFactor: true,
+	}
+	assert.NoError(t, store.CreateSession(ctx, session))
+	assert.IsError(t, store.CreateSession(ctx, session), sessions.ErrExists)
+
+	assert.NoError(t, store.CreateCode(ctx, sessions.Code{
+		Code:        "1234567",
+		PhoneNumber: "+15555550123",
+		CreatedAt:   now.Add(-time.Hour),
+		ExpiresAt:   now.Add(-time.Minute),
+	}))
+
+	assert.NoError(t, store.CreateCode(ctx, sessions.Code{
+		Code:        "7654321",
+		PhoneNumber: "+15555550123",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}))
+	assert.NoError(t, store.AddCodeAttempt(ctx, "+15555550123"))
+
+	codes, err := store.Codes(ctx, "+15555550123")
+	assert.NoError(t, err)
+	assert.Equal(t, []sessions.Code{{
+		Code:        "7654321",
+		PhoneNumber: "+15555550123",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+		Attempts:    1,
+	}}, codes)
+
+	_, err = store.UseCode(ctx, "+15555550123", "1234567", 5)
+	assert.IsError(t, err, sessions.ErrUsed, "expired code")
+	_, err = store.UseCode(ctx, "+15555550100", "7654321", 5)
+	assert.IsError(t, err, sessions.ErrUsed, "wrong phone number")
+	_, err = store.UseCode(ctx, "+15555550123", "7654321", 1)
+	assert.IsError(t, err, sessions.ErrUsed, "too many attempts")
+
+	code, err := store.UseCode(ctx, "+15555550123", "7654321", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, codes[0], code)
+
+	_, err = store.UseCode(ctx, "+15555550123", "7654321", 5)
+	assert.IsError(t, err, sessions.ErrUsed, "used code")
+
+	// Reopen the store to ensure that everything is persisted.
+	assert.NoError(t, store.Close())
+	store, err = NewSessionStore(ctx, storage, logger)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	got, err := store.Session(ctx, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, session, got)
+
+	assert.NoError(t, store.DeleteExpired(ctx, now.Add(2*time.Hour)))
+
+	_, err = store.Session(ctx, session.ID)
+	assert.IsError(t, err, sessions.ErrNotFound)
+
+	key := sessions.APIKey{
+		ID:          sessions.TokenID("twk_key"),
+		Name:        "automation",
+		Scopes:      []sessions.Scope{sessions.ScopeSendMessages, sessions.ScopeManageConfig},
+		PhoneNumber: "+15555550123",
+		CreatedAt:   now,
+	}
+	assert.NoError(t, store.CreateAPIKey(ctx, key))
+
+	key.ID = sessions.TokenID("twk_other")
+	assert.IsError(t, store.CreateAPIKey(ctx, key), sessions.ErrExists)
+	key.ID = sessions.TokenID("twk_key")
+
+	gotKey, err := store.APIKey(ctx, key.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, key, gotKey)
+
+	assert.NoError(t, store.DeleteAPIKey(ctx, "automation"))
+	assert.IsError(t, store.DeleteAPIKey(ctx, "automation"), sessions.ErrNotFound)
+}
+
+func TestSessionStoreTOTP(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	storage := storage.New(config.Storage{Path: t.TempDir()}, logger)
+
+	store, err := NewSessionStore(ctx, storage, logger)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	const number = "+15555550123"
+	now := time.Unix(time.Now().Unix(), 0)
+
+	_, err = store.TOTP(ctx, number)
+	assert.IsError(t, err, sessions.ErrNotFound)
+
+	totp := sessions.TOTP{
+		PhoneNumber: number,
+		Secret:      []byte("old secret"),
+		CreatedAt:   now,
+	}
+	assert.NoError(t, store.CreateTOTP(ctx, totp))
+
+	// Unconfirmed enrolments can be replaced.
+	totp.Secret = []byte("new secret")
+	assert.NoError(t, store.CreateTOTP(ctx, totp))
+
+	assert.NoError(t, store.ConfirmTOTP(ctx, number, []string{"a", "b"}))
+	assert.IsError(t, store.CreateTOTP(ctx, totp), sessions.ErrExists)
+
+	got, err := store.TOTP(ctx, number)
+	assert.NoError(t, err)
+	totp.Confirmed = true
+	assert.Equal(t, totp, got)
+
+	// Each step can only be used once.
+	assert.NoError(t, store.UseTOTPStep(ctx, number, 10))
+	assert.IsError(t, store.UseTOTPStep(ctx, number, 10), sessions.ErrUsed)
+	assert.IsError(t, store.UseTOTPStep(ctx, number, 9), sessions.ErrUsed)
+	assert.NoError(t, store.UseTOTPStep(ctx, number, 11))
+
+	assert.NoError(t, store.UseRecoveryCode(ctx, number, "a"))
+	assert.IsError(t, store.UseRecoveryCode(ctx, number, "a"), sessions.ErrNotFound)
+
+	left, err := store.RecoveryCodesLeft(ctx, number)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, left)
+
+	assert.NoError(t, store.SetRecoveryCodes(ctx, number, []string{"c", "d", "e"}))
+	assert.IsError(t, store.UseRecoveryCode(ctx, number, "b"), sessions.ErrNotFound)
+
+	assert.NoError(t, store.DeleteTOTP(ctx, number))
+	assert.IsError(t, store.DeleteTOTP(ctx, number), sessions.ErrNotFound)
+
+	left, err = store.RecoveryCodesLeft(ctx, number)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, left)
+}
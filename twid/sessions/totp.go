@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TOTP is the TOTP second factor enrolment of a phone number.
+type TOTP struct {
+	PhoneNumber string
+	Secret      []byte
+	// Confirmed is true once the user has proven that their authenticator is
+	// set up by entering a code. Unconfirmed enrolments are not enforced.
+	Confirmed bool
+	CreatedAt time.Time
+	// LastStep is the time step of the last accepted code. Codes of it and
+	// earlier steps are rejected, so that each code can only be used once.
+	LastStep int64
+}
+
+// RecoveryCodeCount is the number of recovery codes generated at once.
+const RecoveryCodeCount = 10
+
+// NewRecoveryCodes generates a new set of recovery codes. Each code can be
+// used once in place of a TOTP code. Only their [TokenID] is stored.
+func NewRecoveryCodes() ([]string, error) {
+	// Crockford's alphabet avoids characters that are easily confused. It has
+	// 32 characters, so every character is equally likely.
+	const alphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		var r [10]byte
+		if _, err := rand.Read(r[:]); err != nil {
+			return nil, fmt.Errorf("could not generate recovery code: %w", err)
+		}
+
+		code := make([]byte, 0, len(r)+1)
+		for j, b := range r {
+			if j == len(r)/2 {
+				code = append(code, '-')
+			}
+			code = append(code, alphabet[int(b)%len(alphabet)])
+		}
+		codes[i] = string(code)
+	}
+
+	return codes, nil
+}
+
+// RecoveryCodeID returns the ID of a recovery code as entered by a user. Case
+// and surrounding spaces are ignored.
+func RecoveryCodeID(code string) string {
+	return TokenID(strings.ToLower(strings.TrimSpace(code)))
+}
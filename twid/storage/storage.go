@@ -0,0 +1,109 @@
+// Package storage provides the persistent storage shared by twid and its
+// modules.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/twipi/twipi/twid/config"
+	"libdb.so/lazymigrate"
+
+	_ "modernc.org/sqlite"
+)
+
+const pragma = `
+	PRAGMA journal_mode=WAL2;
+	PRAGMA foreign_keys=ON;
+	PRAGMA strict=ON;
+`
+
+var nameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Storage is a handle to twid's persistent storage. Each user of the storage
+// gets its own database, so that schemas can be migrated independently.
+type Storage struct {
+	cfg    config.Storage
+	logger *slog.Logger
+}
+
+// New creates a new Storage using the given configuration.
+func New(cfg config.Storage, logger *slog.Logger) *Storage {
+	return &Storage{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// InMemory returns true if the storage does not persist anything to disk.
+func (s *Storage) InMemory() bool {
+	return s.cfg.InMemory
+}
+
+// Dir returns the directory in which the databases are kept. It is only
+// meaningful if the storage is not in memory.
+func (s *Storage) Dir() (string, error) {
+	if s.cfg.Path != "" {
+		return s.cfg.Path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find default storage directory: %w", err)
+	}
+
+	return filepath.Join(dir, "twid"), nil
+}
+
+// OpenSQLite opens the SQLite database with the given name, creating it if it
+// doesn't exist yet, and migrates it to the given schema. The schema is in the
+// format expected by [lazymigrate.Migrate].
+//
+// The name must only contain lowercase letters, digits and underscores. The
+// caller is responsible for closing the returned database.
+func (s *Storage) OpenSQLite(ctx context.Context, name, schema string) (*sql.DB, error) {
+	if !nameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid database name %q", name)
+	}
+
+	var uri string
+	if s.InMemory() {
+		uri = fmt.Sprintf("file:twid_%s?mode=memory&cache=shared", name)
+	} else {
+		dir, err := s.Dir()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("could not create storage directory: %w", err)
+		}
+		uri = filepath.Join(dir, name+".sqlite3")
+	}
+
+	s.logger.Debug(
+		"opening SQLite database",
+		"name", name,
+		"uri", uri)
+
+	db, err := sql.Open("sqlite", uri)
+	if err != nil {
+		return nil, fmt.Errorf("could not open SQLite database %q: %w", name, err)
+	}
+
+	if _, err := db.ExecContext(ctx, pragma); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not set SQLite PRAGMA: %w", err)
+	}
+
+	if err := lazymigrate.Migrate(ctx, db, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not migrate SQLite database %q: %w", name, err)
+	}
+
+	return db, nil
+}
@@ -17,7 +17,7 @@ var twicmdParsers = map[string]TwicmdParser{}
 // TwicmdParser describes a Twicmd parser module.
 type TwicmdParser struct {
 	Name string
-	New  func(cfg json.RawMessage, logger *slog.Logger) (twicmd.CommandParser, error)
+	New  func(cfg json.RawMessage, mctx ModuleContext) (twicmd.CommandParser, error)
 }
 
 // RegisterTwicmdParser registers a new Twicmd parser module globally.
@@ -33,7 +33,7 @@ var twicmdServices = map[string]TwicmdService{}
 // TwicmdService describes a Twicmd service module.
 type TwicmdService struct {
 	Name string
-	New  func(cfg json.RawMessage, logger *slog.Logger) (twicmd.Service, error)
+	New  func(cfg json.RawMessage, mctx ModuleContext) (twicmd.Service, error)
 }
 
 // RegisterTwicmdService registers a new Twicmd service module globally.
@@ -44,86 +44,153 @@ func RegisterTwicmdService(service TwicmdService) {
 	twicmdServices[service.Name] = service
 }
 
-func initializeTwicmd(cfg config.Root, lifecycle *lifecycle, sms twisms.MessageService, logger *slog.Logger) (*twicmd.Manager, error) {
-	var parsers []twicmd.CommandParser
-	for _, cfg := range cfg.Twicmd.Parsers {
-		module, ok := twicmdParsers[cfg.Module]
-		if !ok {
-			return nil, fmt.Errorf("unknown twicmd parser %s", cfg.Module)
-		}
-
-		logger := logger.With(
-			"module", "twicmd",
-			"twicmd.component", "parser",
-			"twicmd.parser", cfg.Module)
-
-		raw, _ := cfg.MarshalJSON()
-
-		parser, err := module.New(raw, logger)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create twicmd parser %s: %w", cfg.Module, err)
-		}
-
-		parsers = append(parsers, parser)
-		lifecycle.add(parser, logger)
+func initializeTwicmd(cfg config.Root, lifecycle *lifecycle, aliases twicmd.AliasStore, defaults twicmd.DefaultServiceStore, mctx ModuleContext) (*twicmd.Manager, error) {
+	parsers, err := initializeTwicmdParsers(cfg.Twicmd.Parsers, lifecycle, mctx)
+	if err != nil {
+		return nil, err
 	}
 
-	services := twicmd.NewServiceLookup()
 	for _, cfg := range cfg.Twicmd.Services {
 		module, ok := twicmdServices[cfg.Module]
 		if !ok {
 			return nil, fmt.Errorf("unknown twicmd service %s", cfg.Module)
 		}
 
-		logger := logger.With(
+		logger := mctx.Logger.With(
 			"module", "twicmd",
 			"twicmd.component", "service",
 			"twicmd.service", cfg.Module)
 
-		raw, _ := cfg.MarshalJSON()
-
-		service, err := module.New(raw, logger)
+		service, err := module.New(cfg.ModuleConfig(), mctx.withLogger(logger))
 		if err != nil {
 			return nil, fmt.Errorf("cannot create twicmd service %s: %w", cfg.Module, err)
 		}
 
-		services.Register(service)
+		if err := mctx.Services.Register(service); err != nil {
+			return nil, fmt.Errorf("cannot register twicmd service %s: %w", cfg.Module, err)
+		}
 		lifecycle.add(service, logger)
+		lifecycle.add(messageRelay{service, mctx.SMS, logger}, logger)
+	}
 
-		// TODO: make module.New accept sms so we don't have to handle this
-		// ourselves.
-
-		lifecycle.starters = append(lifecycle.starters,
-			starterFunc(func(ctx context.Context) error {
-				ch := make(chan *twismsproto.Message)
-				service.SubscribeMessages(ch, nil)
-				defer service.UnsubscribeMessages(ch)
-				for {
-					select {
-					case <-ctx.Done():
-						return ctx.Err()
-					case msg := <-ch:
-						sms.SendMessage(ctx, msg)
-					}
-				}
-			}),
-		)
+	pipelines := make([]twicmd.Pipeline, len(cfg.Twicmd.Pipelines))
+	for i, cfg := range cfg.Twicmd.Pipelines {
+		pipeline, err := initializeTwicmdPipeline(cfg, parsers, lifecycle, mctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create twicmd pipeline %q: %w", cfg.Name, err)
+		}
+		pipelines[i] = pipeline
 	}
 
 	manager := &twicmd.Manager{
-		SMS:      sms,
-		Parsers:  parsers,
-		Services: services,
-		Logger:   logger.With("module", "twicmd"),
-		Opts:     twicmd.StartOpts{},
+		SMS:             mctx.SMS,
+		Parsers:         parsers,
+		Services:        mctx.Services,
+		Pipelines:       pipelines,
+		Aliases:         aliases,
+		DefaultServices: defaults,
+		Logger:          mctx.Logger.With("module", "twicmd"),
+		Opts: twicmd.StartOpts{
+			Prompts: twicmd.PromptOpts{
+				Disable:       cfg.Twicmd.Prompts.Disable,
+				Timeout:       cfg.Twicmd.Prompts.Timeout.AsDuration(),
+				CancelKeyword: cfg.Twicmd.Prompts.CancelKeyword,
+			},
+			Sticky: twicmd.StickyOpts{
+				Disable: cfg.Twicmd.StickyMode.Disable,
+				Timeout: cfg.Twicmd.StickyMode.Timeout.AsDuration(),
+			},
+		},
 	}
 
 	lifecycle.add(manager, manager.Logger)
 	return manager, nil
 }
 
-type starterFunc func(ctx context.Context) error
+func initializeTwicmdParsers(cfgs []config.TwicmdParser, lifecycle *lifecycle, mctx ModuleContext) ([]twicmd.CommandParser, error) {
+	parsers := make([]twicmd.CommandParser, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		module, ok := twicmdParsers[cfg.Module]
+		if !ok {
+			return nil, fmt.Errorf("unknown twicmd parser %s", cfg.Module)
+		}
 
-func (f starterFunc) Start(ctx context.Context) error {
-	return f(ctx)
+		logger := mctx.Logger.With(
+			"module", "twicmd",
+			"twicmd.component", "parser",
+			"twicmd.parser", cfg.Module)
+
+		parser, err := module.New(cfg.ModuleConfig(), mctx.withLogger(logger))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create twicmd parser %s: %w", cfg.Module, err)
+		}
+
+		parsers = append(parsers, parser)
+		lifecycle.add(parser, logger)
+	}
+	return parsers, nil
+}
+
+// initializeTwicmdPipeline creates a pipeline from the given configuration.
+// The pipeline inherits the default parsers and all services unless it
+// configures its own.
+func initializeTwicmdPipeline(cfg config.TwicmdPipeline, defaultParsers []twicmd.CommandParser, lifecycle *lifecycle, mctx ModuleContext) (twicmd.Pipeline, error) {
+	pipeline := twicmd.Pipeline{
+		Name:         cfg.Name,
+		Filters:      cfg.Filters.MessageFilters,
+		Parsers:      defaultParsers,
+		Services:     mctx.Services,
+		FallbackText: cfg.FallbackText,
+	}
+
+	if len(cfg.Parsers) > 0 {
+		mctx := mctx.withLogger(mctx.Logger.With("twicmd.pipeline", cfg.Name))
+
+		parsers, err := initializeTwicmdParsers(cfg.Parsers, lifecycle, mctx)
+		if err != nil {
+			return pipeline, err
+		}
+		pipeline.Parsers = parsers
+	}
+
+	if len(cfg.Services) > 0 {
+		// Services disabled by admins must be disabled in every pipeline, so
+		// the pipeline shares its disabled services with the default one.
+		services, err := mctx.Services.Subset(cfg.Services)
+		if err != nil {
+			return pipeline, fmt.Errorf("invalid twicmd services: %w", err)
+		}
+		pipeline.Services = services
+	}
+
+	return pipeline, nil
+}
+
+// messageRelay sends all messages emitted by a Twicmd service out through the
+// composite SMS service, so that services don't have to do it themselves.
+type messageRelay struct {
+	service twicmd.Service
+	sms     twisms.MessageSender
+	logger  *slog.Logger
+}
+
+// Start implements [Starter].
+func (r messageRelay) Start(ctx context.Context) error {
+	ch := make(chan *twismsproto.Message)
+	r.service.SubscribeMessages(ch, nil)
+	defer r.service.UnsubscribeMessages(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-ch:
+			if err := r.sms.SendMessage(ctx, msg); err != nil {
+				r.logger.Error(
+					"failed to send message from service",
+					"to", msg.To,
+					"err", err)
+			}
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package twid
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twicmdproto"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twicmd"
+	"github.com/twipi/twipi/twid/config"
+)
+
+// testTwicmdService is a Twicmd service that replies with its own name.
+type testTwicmdService struct{ name string }
+
+func (s testTwicmdService) Name() string { return s.name }
+
+func (s testTwicmdService) Service(context.Context) (*twicmdproto.Service, error) {
+	return &twicmdproto.Service{
+		Name:     s.name,
+		Commands: []*twicmdproto.CommandDescription{{Name: "ping"}},
+	}, nil
+}
+
+func (s testTwicmdService) Execute(context.Context, *twicmdproto.ExecuteRequest) (*twicmdproto.ExecuteResponse, error) {
+	return twicmd.TextResponse(s.name), nil
+}
+
+func (s testTwicmdService) SubscribeMessages(chan<- *twismsproto.Message, *twismsproto.MessageFilters) {
+}
+
+func (s testTwicmdService) UnsubscribeMessages(chan<- *twismsproto.Message) {}
+
+func TestTwicmdPipelineDisabledServices(t *testing.T) {
+	ctx := context.Background()
+
+	mctx := ModuleContext{
+		Services: twicmd.NewServiceLookup(),
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	assert.NoError(t, mctx.Services.Register(testTwicmdService{"weather"}))
+	assert.NoError(t, mctx.Services.Register(testTwicmdService{"discord"}))
+
+	pipeline, err := initializeTwicmdPipeline(config.TwicmdPipeline{
+		Name:     "weather",
+		Services: []string{"weather"},
+	}, nil, &lifecycle{}, mctx)
+	assert.NoError(t, err)
+
+	manager := &twicmd.Manager{
+		Services:  mctx.Services,
+		Pipelines: []twicmd.Pipeline{pipeline},
+		Logger:    mctx.Logger,
+	}
+	ping := func() (*twicmdproto.ExecuteResponse, error) {
+		return manager.ExecuteCommand(ctx, &twismsproto.Message{From: "+15555550123"}, &twicmdproto.Command{
+			Service: "weather",
+			Command: "ping",
+		})
+	}
+
+	resp, err := ping()
+	assert.NoError(t, err)
+	assert.Equal(t, twicmd.TextResponse("weather"), resp)
+
+	_, ok := pipeline.Services.Service("discord")
+	assert.False(t, ok, "pipeline has a service it wasn't given")
+
+	// Admins disable services in the lookup of all services.
+	assert.True(t, mctx.Services.SetDisabled("weather", true))
+
+	_, err = ping()
+	assert.IsError(t, err, twicmd.ErrUnknownService)
+}
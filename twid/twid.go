@@ -6,12 +6,22 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/twipi/twipi/internal/srvutil"
+	"github.com/twipi/twipi/twicmd"
+	aliassqlite "github.com/twipi/twipi/twid/aliases/sqlite"
 	"github.com/twipi/twipi/twid/api"
+	auditsqlite "github.com/twipi/twipi/twid/audit/sqlite"
 	"github.com/twipi/twipi/twid/config"
+	defaultsqlite "github.com/twipi/twipi/twid/defaultservices/sqlite"
+	"github.com/twipi/twipi/twid/sessions"
+	sessionsqlite "github.com/twipi/twipi/twid/sessions/sqlite"
+	"github.com/twipi/twipi/twid/storage"
+	"github.com/twipi/twipi/twid/webui"
 	"golang.org/x/sync/errgroup"
 	"libdb.so/hserve"
 )
@@ -27,6 +37,10 @@ import (
 --
 */
 
+// sessionSweepInterval is the interval at which expired login codes and
+// sessions are deleted.
+const sessionSweepInterval = 15 * time.Minute
+
 // Start starts the twid daemon. It runs until the context is canceled.
 func Start(ctx context.Context, cfg config.Root, logger *slog.Logger) error {
 	errg, ctx := errgroup.WithContext(ctx)
@@ -38,18 +52,76 @@ func Start(ctx context.Context, cfg config.Root, logger *slog.Logger) error {
 	router.Get("/health", srvutil.Respond200)
 	router.Mount("/metrics", promhttp.Handler())
 
-	sms, err := initializeTwisms(cfg, lifecycle, router, logger)
+	sms := newTwismsWrapper(logger.With("module", "twisms"))
+	mctx := ModuleContext{
+		SMS:      sms,
+		Services: twicmd.NewServiceLookup(),
+		Storage:  storage.New(cfg.Storage, logger.With("module", "storage")),
+		Metrics:  prometheus.DefaultRegisterer,
+		Logger:   logger,
+	}
+
+	conversationLogger := logger.With("module", "conversations")
+	conversations, err := newConversationLog(ctx, mctx.Storage, conversationLogger)
 	if err != nil {
+		return fmt.Errorf("failed to initialize conversation log: %w", err)
+	}
+	lifecycle.add(conversations, conversationLogger)
+	sms.conversations = conversations
+
+	if err := initializeTwisms(cfg, lifecycle, router, sms, mctx); err != nil {
 		return fmt.Errorf("failed to initialize TwiSMS: %w", err)
 	}
 
-	cmd, err := initializeTwicmd(cfg, lifecycle, sms, logger)
+	aliasLogger := logger.With("module", "aliases")
+	aliasStore, err := aliassqlite.NewAliasStore(ctx, mctx.Storage, aliasLogger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize alias store: %w", err)
+	}
+	lifecycle.add(aliasStore, aliasLogger)
+
+	defaultsLogger := logger.With("module", "default_services")
+	defaultsStore, err := defaultsqlite.NewDefaultServiceStore(ctx, mctx.Storage, defaultsLogger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize default service store: %w", err)
+	}
+	lifecycle.add(defaultsStore, defaultsLogger)
+
+	cmd, err := initializeTwicmd(cfg, lifecycle, aliasStore, defaultsStore, mctx)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Twicmd: %w", err)
 	}
 
-	router.Mount("/api/services",
-		api.New(sms, cmd, logger.With("module", "api")))
+	sessionLogger := logger.With("module", "sessions")
+	sessionStore, err := sessionsqlite.NewSessionStore(ctx, mctx.Storage, sessionLogger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize session store: %w", err)
+	}
+	lifecycle.add(sessionStore, sessionLogger)
+	lifecycle.add(sessions.NewSweeper(sessionStore, sessionSweepInterval, sessionLogger), sessionLogger)
+
+	auditLogger := logger.With("module", "audit")
+	auditLog, err := auditsqlite.NewAuditLog(ctx, mctx.Storage, auditLogger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize audit log: %w", err)
+	}
+	lifecycle.add(auditLog, auditLogger)
+
+	apiHandler, err := api.New(cfg.API, api.Backend{
+		SMS:           sms,
+		Commands:      cmd,
+		Sessions:      sessionStore,
+		Conversations: conversations,
+		Audit:         auditLog,
+		LoginSMS:      sms.unrecorded(),
+	}, logger.With("module", "api"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize API: %w", err)
+	}
+	router.Mount("/api", apiHandler)
+	router.
+		With(api.SecurityHeaders(cfg.API.SecurityHeaders, webui.ContentSecurityPolicy)).
+		Handle("/*", webui.Handler())
 
 	errg.Go(func() error {
 		logger.Info("starting all services")
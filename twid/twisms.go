@@ -3,15 +3,23 @@ package twid
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/twipi/pubsub"
 	"github.com/twipi/twipi/proto/out/twismsproto"
 	"github.com/twipi/twipi/twid/config"
 	"github.com/twipi/twipi/twisms"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 var twismsModules = map[string]TwismsModule{}
@@ -20,7 +28,7 @@ var twismsModules = map[string]TwismsModule{}
 type TwismsModule struct {
 	Name string
 	Desc string
-	New  func(cfg json.RawMessage, logger *slog.Logger) (twisms.MessageService, error)
+	New  func(cfg json.RawMessage, mctx ModuleContext) (twisms.MessageService, error)
 }
 
 // RegisterTwismsModule registers a new Twisms module globally.
@@ -31,66 +39,237 @@ func RegisterTwismsModule(module TwismsModule) {
 	twismsModules[module.Name] = module
 }
 
-func initializeTwisms(cfg config.Root, lifecycle *lifecycle, router *chi.Mux, logger *slog.Logger) (twisms.MessageService, error) {
-	var services []twisms.MessageService
-
+// initializeTwisms creates all configured Twisms services and adds them into
+// the given wrapper, which is the same one given to modules as
+// [ModuleContext.SMS].
+func initializeTwisms(cfg config.Root, lifecycle *lifecycle, router *chi.Mux, sms *twismsWrapper, mctx ModuleContext) error {
 	for _, serviceCfg := range cfg.Twisms.Services {
 		module, ok := twismsModules[serviceCfg.Module]
 		if !ok {
-			return nil, fmt.Errorf("unknown twisms module %s", serviceCfg.Module)
+			return fmt.Errorf("unknown twisms module %s", serviceCfg.Module)
 		}
 
-		logger := logger.With(
-			"module", "twisms",
-			"twisms_module", module.Name)
+		name := serviceCfg.ServiceName()
+		if slices.ContainsFunc(sms.services, func(s *twismsService) bool { return s.name == name }) {
+			return fmt.Errorf("duplicate twisms service %q, consider setting a name", name)
+		}
 
-		serviceCfgRaw, _ := serviceCfg.MarshalJSON()
+		logger := mctx.Logger.With(
+			"module", "twisms",
+			"twisms_module", module.Name,
+			"twisms_service", name)
 
-		service, err := module.New(serviceCfgRaw, logger)
+		service, err := module.New(serviceCfg.ModuleConfig(), mctx.withLogger(logger))
 		if err != nil {
-			return nil, fmt.Errorf("cannot create twisms service: %w", err)
+			return fmt.Errorf("cannot create twisms service: %w", err)
 		}
 
 		if handler, ok := service.(http.Handler); ok {
 			if err := addRoute(router, serviceCfg, handler, logger); err != nil {
-				return nil, fmt.Errorf("cannot add twisms service route: %w", err)
+				return fmt.Errorf("cannot add twisms service route: %w", err)
 			}
 		}
 
-		services = append(services, service)
+		sms.services = append(sms.services, &twismsService{
+			MessageService: service,
+			name:           name,
+		})
 		lifecycle.add(service, logger)
 	}
 
-	return &twismsWrapper{services: services}, nil
+	routes, err := newTwismsRoutes(cfg.Twisms.Routes, sms.services)
+	if err != nil {
+		return fmt.Errorf("invalid twisms routes: %w", err)
+	}
+	sms.routes = routes
+
+	lifecycle.add(sms, sms.logger)
+	if cfg.Twisms.DebugRoutes {
+		router.Post("/debug/twisms/route", sms.explainRouteHandler)
+	}
+
+	return nil
 }
 
+// twismsWrapper combines all Twisms services into a single
+// [twisms.MessageService]. Outgoing messages are routed according to the
+// configured routing rules.
 type twismsWrapper struct {
-	services []twisms.MessageService
+	services []*twismsService
+	routes   []twismsRoute
+	subs     pubsub.Subscriber[*twismsproto.Message]
+	origins  *xsync.MapOf[twismsConversation, twismsOrigin]
+	logger   *slog.Logger
+
+	// conversations, if not nil, records all incoming and outgoing messages.
+	conversations *conversationLog
 }
 
-var _ twisms.MessageSubscriber = (*twismsWrapper)(nil)
+// twismsConversation is the (from, to) pair of an incoming message.
+type twismsConversation struct {
+	from string
+	to   string
+}
 
-func (s *twismsWrapper) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
-	for _, sub := range s.services {
-		sub.SubscribeMessages(ch, filters)
+// twismsOriginTTL is how long replies to a conversation are sent through the
+// service that its last incoming message arrived on. Afterwards, replies are
+// routed like any other message.
+const twismsOriginTTL = 24 * time.Hour
+
+// twismsOrigin is the service that the last incoming message of a
+// conversation arrived on.
+type twismsOrigin struct {
+	service *twismsService
+	seen    time.Time
+}
+
+func (o twismsOrigin) expired(now time.Time) bool {
+	return now.Sub(o.seen) > twismsOriginTTL
+}
+
+var (
+	_ Starter               = (*twismsWrapper)(nil)
+	_ twisms.MessageService = (*twismsWrapper)(nil)
+	_ twisms.MessageReplier = (*twismsWrapper)(nil)
+)
+
+func newTwismsWrapper(logger *slog.Logger) *twismsWrapper {
+	return &twismsWrapper{
+		origins: xsync.NewMapOf[twismsConversation, twismsOrigin](),
+		logger:  logger,
 	}
 }
 
-func (s *twismsWrapper) UnsubscribeMessages(ch chan<- *twismsproto.Message) {
-	for _, sub := range s.services {
-		sub.UnsubscribeMessages(ch)
+// Start implements [Starter]. It relays the incoming messages of all services
+// to the wrapper's subscribers.
+func (s *twismsWrapper) Start(ctx context.Context) error {
+	errg, ctx := errgroup.WithContext(ctx)
+
+	msgs := make(chan *twismsproto.Message)
+	errg.Go(func() error {
+		return s.subs.Listen(ctx, msgs)
+	})
+
+	for _, service := range s.services {
+		service := service
+		errg.Go(func() error {
+			return s.relayMessages(ctx, service, msgs)
+		})
+	}
+
+	errg.Go(func() error {
+		return s.forgetOrigins(ctx)
+	})
+
+	return errg.Wait()
+}
+
+// forgetOrigins periodically deletes the expired origins of conversations so
+// that they don't accumulate.
+func (s *twismsWrapper) forgetOrigins(ctx context.Context) error {
+	ticker := time.NewTicker(twismsOriginTTL / 24)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			s.origins.Range(func(key twismsConversation, origin twismsOrigin) bool {
+				if origin.expired(now) {
+					s.origins.Delete(key)
+				}
+				return true
+			})
+		}
 	}
 }
 
+func (s *twismsWrapper) relayMessages(ctx context.Context, service *twismsService, dst chan<- *twismsproto.Message) error {
+	ch := make(chan *twismsproto.Message)
+	service.SubscribeMessages(ch, nil)
+	defer service.UnsubscribeMessages(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-ch:
+			// Remember where the message came from so that we can reply
+			// through the same service.
+			s.origins.Store(twismsConversation{msg.From, msg.To}, twismsOrigin{service, time.Now()})
+			s.recordMessage(ctx, msg)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case dst <- msg:
+			}
+		}
+	}
+}
+
+func (s *twismsWrapper) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
+	s.subs.Subscribe(ch, func(msg *twismsproto.Message) bool {
+		return twisms.FilterMessage(filters, msg)
+	})
+}
+
+func (s *twismsWrapper) UnsubscribeMessages(ch chan<- *twismsproto.Message) {
+	s.subs.Unsubscribe(ch)
+}
+
 func (s *twismsWrapper) SendMessage(ctx context.Context, msg *twismsproto.Message) error {
-	var err error
-	for _, sender := range s.services {
-		err = sender.SendMessage(ctx, msg)
+	return s.sendMessage(ctx, msg, true)
+}
+
+func (s *twismsWrapper) sendMessage(ctx context.Context, msg *twismsproto.Message, record bool) error {
+	services, route := routeMessage(s.routes, s.services, msg)
+	if len(services) == 0 {
+		return fmt.Errorf("no twisms service can send from %q (route %s)", msg.From, route.Rule)
+	}
+
+	var errs []error
+	for _, service := range services {
+		err := service.SendMessage(ctx, msg)
 		if err == nil {
+			service.markSucceeded()
+			if record {
+				s.recordMessage(ctx, msg)
+			}
 			return nil
 		}
+
+		service.markFailed()
+		s.logger.Warn(
+			"failed to send message, trying next service",
+			"route", route.Rule,
+			"service", service.name,
+			"err", err)
+
+		errs = append(errs, fmt.Errorf("%s: %w", service.name, err))
 	}
-	return err
+
+	return errors.Join(errs...)
+}
+
+// unrecorded returns a sender that sends messages like the wrapper does
+// without recording them in the conversation log. It is meant for messages
+// that must not be kept, such as login codes.
+func (s *twismsWrapper) unrecorded() twisms.MessageSender {
+	return unrecordedSender{s}
+}
+
+type unrecordedSender struct {
+	wrapper *twismsWrapper
+}
+
+func (s unrecordedSender) SendMessage(ctx context.Context, msg *twismsproto.Message) error {
+	return s.wrapper.sendMessage(ctx, msg, false)
+}
+
+func (s unrecordedSender) SendingNumber() (string, float64) {
+	return s.wrapper.SendingNumber()
 }
 
 func (s *twismsWrapper) SendingNumber() (string, float64) {
@@ -106,13 +285,52 @@ func (s *twismsWrapper) SendingNumber() (string, float64) {
 	return number, score
 }
 
+// ReplyMessage implements [twisms.MessageReplier]. The reply is sent through
+// the service that the original message arrived on, falling back to routing
+// the reply like any other message.
 func (s *twismsWrapper) ReplyMessage(ctx context.Context, msg *twismsproto.Message, body *twismsproto.MessageBody) error {
-	var err error
-	for _, sender := range s.services {
-		err = twisms.ReplyMessage(ctx, sender, msg, body)
+	if origin, ok := s.origins.Load(twismsConversation{msg.From, msg.To}); ok && !origin.expired(time.Now()) {
+		service := origin.service
+		err := twisms.ReplyMessage(ctx, service.MessageService, msg, body)
 		if err == nil {
+			service.markSucceeded()
+			s.recordMessage(ctx, twisms.NewReplyingMessage(msg, body))
 			return nil
 		}
+
+		service.markFailed()
+		s.logger.Warn(
+			"failed to reply through original service, routing reply instead",
+			"service", service.name,
+			"err", err)
 	}
-	return err
+
+	return s.SendMessage(ctx, twisms.NewReplyingMessage(msg, body))
+}
+
+func (s *twismsWrapper) recordMessage(ctx context.Context, msg *twismsproto.Message) {
+	if s.conversations != nil {
+		s.conversations.record(ctx, msg)
+	}
+}
+
+// explainRouteHandler explains how the message in the request body would be
+// routed without sending it. The message is given in Protobuf JSON format.
+func (s *twismsWrapper) explainRouteHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := new(twismsproto.Message)
+	if err := protojson.Unmarshal(b, msg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid message: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	_, explanation := routeMessage(s.routes, s.services, msg)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
 }
@@ -0,0 +1,192 @@
+package twid
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twisms"
+)
+
+// twismsFailureCooldown is the duration that a service is considered unhealthy
+// for after it fails to send a message.
+const twismsFailureCooldown = 30 * time.Second
+
+// twismsService is a Twisms service known to the router.
+type twismsService struct {
+	twisms.MessageService
+	name        string
+	failedUntil atomic.Int64 // unix nanoseconds
+}
+
+// healthy returns true if the service reports itself as healthy and hasn't
+// recently failed to send a message.
+func (s *twismsService) healthy() bool {
+	if r, ok := s.MessageService.(twisms.HealthReporter); ok && !r.Healthy() {
+		return false
+	}
+	return time.Now().UnixNano() >= s.failedUntil.Load()
+}
+
+// ownsNumber returns true if the service can send messages from the given
+// number. Services that don't declare their numbers are assumed to own all
+// numbers.
+func (s *twismsService) ownsNumber(number string) bool {
+	owner, ok := s.MessageService.(twisms.NumberOwner)
+	return !ok || slices.Contains(owner.PhoneNumbers(), number)
+}
+
+func (s *twismsService) markFailed() {
+	s.failedUntil.Store(time.Now().Add(twismsFailureCooldown).UnixNano())
+}
+
+func (s *twismsService) markSucceeded() {
+	s.failedUntil.Store(0)
+}
+
+// twismsRoute is a routing rule with its services resolved.
+type twismsRoute struct {
+	config.TwismsRoute
+	name     string
+	services []*twismsService
+}
+
+func newTwismsRoutes(cfgs []config.TwismsRoute, services []*twismsService) ([]twismsRoute, error) {
+	routes := make([]twismsRoute, len(cfgs))
+	for i, cfg := range cfgs {
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+
+		if len(cfg.Services) == 0 {
+			return nil, fmt.Errorf("route %s: no services", name)
+		}
+
+		for _, country := range cfg.ToCountries {
+			if _, ok := twisms.CountryCallingCode(country); !ok {
+				return nil, fmt.Errorf("route %s: unknown country %q", name, country)
+			}
+		}
+
+		routeServices := make([]*twismsService, len(cfg.Services))
+		for j, serviceName := range cfg.Services {
+			k := slices.IndexFunc(services, func(s *twismsService) bool {
+				return s.name == serviceName
+			})
+			if k == -1 {
+				return nil, fmt.Errorf("route %s: unknown service %q", name, serviceName)
+			}
+			routeServices[j] = services[k]
+		}
+
+		routes[i] = twismsRoute{
+			TwismsRoute: cfg,
+			name:        name,
+			services:    routeServices,
+		}
+	}
+	return routes, nil
+}
+
+// matches returns true if the message matches all criteria of the route.
+func (r *twismsRoute) matches(msg *twismsproto.Message) bool {
+	if len(r.From) > 0 && !slices.Contains(r.From, msg.From) {
+		return false
+	}
+
+	if len(r.ToPrefixes) > 0 && !slices.ContainsFunc(r.ToPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(msg.To, prefix)
+	}) {
+		return false
+	}
+
+	if len(r.ToCountries) > 0 && !slices.ContainsFunc(r.ToCountries, func(country string) bool {
+		return twisms.PhoneNumberInCountry(msg.To, country)
+	}) {
+		return false
+	}
+
+	if len(r.Types) > 0 && !slices.Contains(r.Types, twisms.MessageBodyType(msg.Body)) {
+		return false
+	}
+
+	return true
+}
+
+// routeExplanation explains how a message is routed.
+type routeExplanation struct {
+	// Rule is the name of the matching rule, or "default" if no rule
+	// matched.
+	Rule string `json:"rule"`
+	// Candidates is the list of services in the rule's fallback chain, in the
+	// order that they will be tried.
+	Candidates []routeCandidate `json:"candidates"`
+}
+
+// routeCandidate is a service considered for routing a message.
+type routeCandidate struct {
+	Service string `json:"service"`
+	Healthy bool   `json:"healthy"`
+	// Skipped is the reason that the service will not be tried, if any.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// routeMessage returns the services that the given message should be sent
+// through in order, along with an explanation of the decision. Healthy
+// services are tried first, followed by unhealthy ones as a last resort.
+func routeMessage(routes []twismsRoute, services []*twismsService, msg *twismsproto.Message) ([]*twismsService, routeExplanation) {
+	explanation := routeExplanation{Rule: "default"}
+	chain := services
+
+	for i := range routes {
+		if routes[i].matches(msg) {
+			explanation.Rule = routes[i].name
+			chain = routes[i].services
+			break
+		}
+	}
+
+	healthy := make([]*twismsService, 0, len(chain))
+	var unhealthy []*twismsService
+
+	for _, service := range chain {
+		candidate := routeCandidate{
+			Service: service.name,
+			Healthy: service.healthy(),
+		}
+
+		switch {
+		case !service.ownsNumber(msg.From):
+			candidate.Skipped = fmt.Sprintf("does not own number %q", msg.From)
+		case candidate.Healthy:
+			healthy = append(healthy, service)
+		default:
+			unhealthy = append(unhealthy, service)
+		}
+
+		explanation.Candidates = append(explanation.Candidates, candidate)
+	}
+
+	// Reorder the candidates to reflect the actual order.
+	slices.SortStableFunc(explanation.Candidates, func(a, b routeCandidate) int {
+		return candidateRank(a) - candidateRank(b)
+	})
+
+	return append(healthy, unhealthy...), explanation
+}
+
+func candidateRank(c routeCandidate) int {
+	switch {
+	case c.Skipped != "":
+		return 2
+	case !c.Healthy:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,99 @@
+package twid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/twipi/twipi/proto/out/twismsproto"
+	"github.com/twipi/twipi/twid/config"
+	"github.com/twipi/twipi/twisms"
+)
+
+func TestRouteMessage(t *testing.T) {
+	services := []*twismsService{
+		{name: "us", MessageService: &testOwnedSMSService{testSMSService{true}, []string{"+15550001"}}},
+		{name: "eu", MessageService: &testOwnedSMSService{testSMSService{true}, []string{"+445550002"}}},
+		{name: "any", MessageService: &testSMSService{healthy: true}},
+		{name: "down", MessageService: &testSMSService{healthy: false}},
+	}
+
+	routes, err := newTwismsRoutes([]config.TwismsRoute{
+		{Name: "uk", ToCountries: []string{"gb"}, Services: []string{"eu", "any"}},
+		{Name: "eu-sender", From: []string{"+445550002"}, Services: []string{"eu"}},
+		{Name: "prefix", ToPrefixes: []string{"+1555"}, Types: []string{"text"}, Services: []string{"down", "any"}},
+	}, services)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		msg      *twismsproto.Message
+		rule     string
+		services []string
+	}{
+		{
+			name:     "country",
+			msg:      &twismsproto.Message{From: "+445550002", To: "+447700900"},
+			rule:     "uk",
+			services: []string{"eu", "any"},
+		},
+		{
+			name:     "from",
+			msg:      &twismsproto.Message{From: "+445550002", To: "+33123456"},
+			rule:     "eu-sender",
+			services: []string{"eu"},
+		},
+		{
+			name:     "unhealthy last",
+			msg:      &twismsproto.Message{From: "+15550001", To: "+15559999", Body: twisms.NewTextBody("hi")},
+			rule:     "prefix",
+			services: []string{"any", "down"},
+		},
+		{
+			name:     "default skips unowned numbers",
+			msg:      &twismsproto.Message{From: "+15550001", To: "+15559999"},
+			rule:     "default",
+			services: []string{"us", "any", "down"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, explanation := routeMessage(routes, services, test.msg)
+			assert.Equal(t, test.rule, explanation.Rule)
+
+			names := make([]string, len(chain))
+			for i, service := range chain {
+				names[i] = service.name
+			}
+			assert.Equal(t, test.services, names)
+		})
+	}
+}
+
+func TestNewTwismsRoutes_UnknownService(t *testing.T) {
+	_, err := newTwismsRoutes([]config.TwismsRoute{
+		{Services: []string{"nope"}},
+	}, nil)
+	assert.EqualError(t, err, `route #0: unknown service "nope"`)
+}
+
+type testSMSService struct {
+	healthy bool
+}
+
+func (s *testSMSService) SubscribeMessages(chan<- *twismsproto.Message, *twismsproto.MessageFilters) {
+}
+func (s *testSMSService) UnsubscribeMessages(chan<- *twismsproto.Message) {}
+
+func (s *testSMSService) SendMessage(context.Context, *twismsproto.Message) error { return nil }
+func (s *testSMSService) SendingNumber() (string, float64)                        { return "", 0 }
+
+func (s *testSMSService) Healthy() bool { return s.healthy }
+
+type testOwnedSMSService struct {
+	testSMSService
+	numbers []string
+}
+
+func (s *testOwnedSMSService) PhoneNumbers() []string { return s.numbers }
@@ -0,0 +1,774 @@
+// The twid control panel. It is a single page application that talks to the
+// JSON API under api and routes using the URL hash:
+//
+//   #/                         the service list
+//   #/login                    logging in using a phone number and a code
+//   #/services/NAME            the manual page of a service
+//   #/services/NAME/settings   the control panel of a service
+//   #/security                 TOTP and recovery codes
+
+const apiBase = "api";
+const tokenKey = "twid.session";
+
+// session keeps the login token in local storage.
+const session = {
+	get() {
+		try {
+			const s = JSON.parse(localStorage.getItem(tokenKey));
+			if (s && new Date(s.expiresAt) > new Date()) {
+				return s;
+			}
+		} catch {}
+		return null;
+	},
+	set(resp) {
+		localStorage.setItem(tokenKey, JSON.stringify(resp));
+	},
+	clear() {
+		localStorage.removeItem(tokenKey);
+	},
+};
+
+class APIError extends Error {
+	constructor(status, message) {
+		super(message);
+		this.status = status;
+	}
+}
+
+// api calls the API. GET requests carry the body in the params query
+// parameter. Errors are returned by the API as "CODE: message".
+async function api(method, path, body) {
+	const headers = {};
+	const init = { method, headers };
+	let url = apiBase + path;
+
+	const s = session.get();
+	if (s) {
+		headers["Authorization"] = `Bearer ${s.token}`;
+	}
+
+	if (method === "GET") {
+		// The parameters are required, even if there are none.
+		url += "?params=" + encodeURIComponent(JSON.stringify(body ?? {}));
+	} else if (body !== undefined) {
+		headers["Content-Type"] = "application/json";
+		init.body = JSON.stringify(body);
+	}
+
+	const resp = await fetch(url, init);
+	const text = await resp.text();
+	if (!resp.ok) {
+		if (resp.status === 401 && s) {
+			session.clear();
+			renderNav();
+		}
+		const message = text.trim().replace(/^\d+: /, "");
+		throw new APIError(resp.status, message || resp.statusText);
+	}
+
+	return text ? JSON.parse(text) : {};
+}
+
+// h creates an element. Attributes starting with "on" are event listeners.
+function h(tag, attrs = {}, ...children) {
+	const el = document.createElement(tag);
+	for (const [key, value] of Object.entries(attrs)) {
+		if (value === undefined || value === null || value === false) {
+			continue;
+		}
+		if (key.startsWith("on")) {
+			el.addEventListener(key.slice(2), value);
+		} else if (key === "style") {
+			for (const [prop, v] of Object.entries(value)) {
+				el.style.setProperty(prop, v);
+			}
+		} else if (key in el && typeof value !== "string") {
+			el[key] = value;
+		} else {
+			el.setAttribute(key, value === true ? "" : value);
+		}
+	}
+	el.append(...nodes(children));
+	return el;
+}
+
+// nodes flattens children and skips the results of false conditions, such as
+// cond && h(...).
+function nodes(children) {
+	return children.flat(Infinity).filter((c) => c !== undefined && c !== null && c !== false);
+}
+
+const main = document.getElementById("main");
+const nav = document.getElementById("nav");
+
+function show(...children) {
+	main.replaceChildren(...nodes(children));
+}
+
+function showError(err) {
+	show(h("p", { class: "error" }, err.message));
+}
+
+function serviceStyle(service) {
+	return service.color ? { "--service-color": service.color } : undefined;
+}
+
+// webURL returns the URL if it is an http or https URL and undefined
+// otherwise. Service descriptions come from other processes, so their URLs
+// must not be able to run scripts here, e.g. using javascript: URLs.
+function webURL(url) {
+	if (!url) {
+		return undefined;
+	}
+	try {
+		const parsed = new URL(url);
+		if (parsed.protocol === "http:" || parsed.protocol === "https:") {
+			return parsed.href;
+		}
+	} catch {}
+	return undefined;
+}
+
+function serviceTitle(service, heading) {
+	const icon = webURL(service.iconUrl);
+	return h(
+		"div",
+		{ class: "service-title" },
+		icon && h("img", { src: icon, alt: "" }),
+		h(heading, {}, service.humanName || service.name),
+	);
+}
+
+function renderNav() {
+	if (session.get()) {
+		nav.replaceChildren(h("a", { href: "#/security" }, "Security"), h("button", { onclick: logout }, "Log out"));
+	} else {
+		nav.replaceChildren(h("a", { href: "#/login" }, "Log in"));
+	}
+}
+
+async function logout() {
+	try {
+		await api("POST", "/logout");
+	} catch (err) {
+		console.error("failed to log out:", err);
+	}
+	session.clear();
+	renderNav();
+	location.hash = "#/";
+}
+
+async function renderServices() {
+	const resp = await api("GET", "/services");
+	const services = resp.services || [];
+	if (services.length === 0) {
+		show(h("p", { class: "muted" }, "No services are available."));
+		return;
+	}
+
+	show(
+		h("h1", {}, "Services"),
+		h(
+			"ul",
+			{ class: "services" },
+			services.map((service) =>
+				h(
+					"li",
+					{},
+					h(
+						"a",
+						{
+							class: "card",
+							href: `#/services/${encodeURIComponent(service.name)}`,
+							style: serviceStyle(service),
+						},
+						serviceTitle(service, "h2"),
+						h("p", { class: "muted" }, service.description),
+					),
+				),
+			),
+		),
+	);
+}
+
+function renderLogin() {
+	if (session.get()) {
+		location.hash = "#/";
+		return;
+	}
+
+	const status = h("p", { class: "error" });
+	const phone = h("input", {
+		type: "tel",
+		name: "phone",
+		autocomplete: "tel",
+		placeholder: "+15555550123",
+		required: true,
+	});
+	const code = h("input", {
+		type: "text",
+		name: "code",
+		inputmode: "numeric",
+		autocomplete: "one-time-code",
+		required: true,
+	});
+	const codeLabel = h("label", { hidden: true }, "Code", code);
+	const secondFactor = h("input", {
+		type: "text",
+		name: "second_factor",
+		autocomplete: "one-time-code",
+		required: true,
+	});
+	const secondFactorLabel = h("label", { hidden: true }, "Authenticator or recovery code", secondFactor);
+	const submit = h("button", { type: "submit", class: "primary" }, "Send code");
+
+	let phase = 1;
+	const form = h(
+		"form",
+		{
+			onsubmit: async (ev) => {
+				ev.preventDefault();
+				status.textContent = "";
+				submit.disabled = true;
+				try {
+					if (phase === 1) {
+						await api("POST", "/login/phase1", { phoneNumber: phone.value });
+						phase = 2;
+						phone.readOnly = true;
+						codeLabel.hidden = false;
+						submit.textContent = "Log in";
+						code.focus();
+					} else {
+						const resp = await api("POST", "/login/phase2", {
+							phoneNumber: phone.value,
+							code: code.value,
+							secondFactor: phase === 3 ? secondFactor.value : undefined,
+						});
+						if (resp.secondFactorRequired) {
+							// The login code stays valid until it is used
+							// along with the second factor.
+							phase = 3;
+							code.readOnly = true;
+							secondFactorLabel.hidden = false;
+							secondFactor.focus();
+							return;
+						}
+						session.set(resp);
+						renderNav();
+						location.hash = "#/";
+					}
+				} catch (err) {
+					status.textContent = err.message;
+				} finally {
+					submit.disabled = false;
+				}
+			},
+		},
+		h(
+			"fieldset",
+			{},
+			h("legend", {}, "Log in"),
+			h("p", { class: "muted" }, "A login code will be sent to your phone number."),
+			h("label", {}, "Phone number", phone),
+			codeLabel,
+			secondFactorLabel,
+		),
+		h("div", { class: "actions" }, submit, status),
+	);
+
+	show(form);
+	phone.focus();
+}
+
+function hintName(hint) {
+	if (!hint || hint === "COMMAND_ARGUMENT_HINT_UNSPECIFIED") {
+		return "";
+	}
+	return hint.replace("COMMAND_ARGUMENT_HINT_", "").toLowerCase().replaceAll("_", " ");
+}
+
+// commandUsage returns the slash command usage of a command.
+function commandUsage(service, command) {
+	const args = command.arguments || {};
+	const words = [`/${service.name}`, command.name];
+
+	const positions = command.argumentPositions || [];
+	if (positions.length > 0) {
+		positions.forEach((name, i) => {
+			const trailing = command.argumentTrailing && i === positions.length - 1;
+			const word = trailing ? `${name}...` : name;
+			words.push(args[name]?.required ? `<${word}>` : `[${word}]`);
+		});
+	} else {
+		for (const [name, arg] of Object.entries(args)) {
+			words.push(arg.required ? `${name}=<value>` : `[${name}=<value>]`);
+		}
+	}
+
+	return words.join(" ");
+}
+
+function renderCommand(service, command) {
+	const args = Object.entries(command.arguments || {});
+	return h(
+		"section",
+		{ class: "card" },
+		h("h3", {}, command.name),
+		h("pre", { class: "usage" }, commandUsage(service, command)),
+		command.description && h("p", {}, command.description),
+		args.length > 0 &&
+			h(
+				"table",
+				{},
+				h("thead", {}, h("tr", {}, h("th", {}, "Argument"), h("th", {}, "Type"), h("th", {}, "Description"))),
+				h(
+					"tbody",
+					{},
+					args.map(([name, arg]) =>
+						h(
+							"tr",
+							{},
+							h("td", {}, h("code", {}, name), !arg.required && h("span", { class: "muted" }, " (optional)")),
+							h("td", {}, hintName(arg.hint)),
+							h("td", {}, arg.description),
+						),
+					),
+				),
+			),
+	);
+}
+
+async function renderManual(name) {
+	const { service } = await api("GET", `/services/${encodeURIComponent(name)}`);
+	const commands = service.commands || [];
+	const website = webURL(service.websiteUrl);
+
+	show(
+		h(
+			"div",
+			{ class: "card", style: serviceStyle(service) },
+			serviceTitle(service, "h1"),
+			h("p", {}, service.description),
+			h(
+				"div",
+				{ class: "actions" },
+				website && h("a", { href: website, rel: "noreferrer" }, "Website"),
+				service.optionsSchema && h("a", { href: `#/services/${encodeURIComponent(service.name)}/settings` }, "Settings"),
+			),
+		),
+		h("h2", {}, "Commands"),
+		commands.length > 0
+			? commands.map((command) => renderCommand(service, command))
+			: h("p", { class: "muted" }, "This service has no commands."),
+	);
+}
+
+// Each option control has an element and a read function. read returns the
+// OptionValue to apply, or null if the value is unchanged.
+
+function stringControl(option, type, value, hidden) {
+	const current = value?.string ?? "";
+	// Sensitive values may be hidden altogether, in which case they can't be
+	// changed either.
+	const locked = type.sensitive && hidden;
+	const input = h("input", {
+		type: type.sensitive ? "password" : "text",
+		value: type.sensitive ? "" : current,
+		// Sensitive values are never shown, so they can only be replaced.
+		placeholder: locked ? "(requires logging in using TOTP)" : type.sensitive && current ? "(unchanged)" : undefined,
+		required: type.required && !locked && !(type.sensitive && current),
+		disabled: locked,
+		autocomplete: type.sensitive ? "new-password" : "off",
+	});
+	return {
+		element: input,
+		read() {
+			if (type.sensitive ? input.value === "" : input.value === current) {
+				return null;
+			}
+			return { id: option.id, string: input.value };
+		},
+	};
+}
+
+function numberControl(option, type, value) {
+	// 64-bit integers are encoded as strings.
+	const current = value?.int ?? "0";
+	const input = h("input", {
+		type: "number",
+		value: current,
+		step: "1",
+		min: type.min,
+		max: type.max,
+		required: true,
+	});
+	return {
+		element: input,
+		read() {
+			if (input.value === String(current)) {
+				return null;
+			}
+			return { id: option.id, int: input.value };
+		},
+	};
+}
+
+function switchControl(option, type, value) {
+	const current = value?.switch ?? false;
+	const input = h("input", { type: "checkbox", checked: current });
+	return {
+		element: input,
+		read() {
+			if (input.checked === current) {
+				return null;
+			}
+			return { id: option.id, switch: input.checked };
+		},
+	};
+}
+
+// stringListControl edits a string list as a table. If the option has
+// structuring columns, each value is split into columns by the separator.
+function stringListControl(option, type, value) {
+	const current = value?.stringList?.values ?? [];
+	const separator = type.structuringSeparator;
+	const columns = separator && type.structuringColumns?.length ? type.structuringColumns : null;
+	const width = columns ? columns.length : 1;
+
+	const tbody = h("tbody");
+	const addRow = (value = "") => {
+		const cells = columns ? value.split(separator) : [value];
+		const inputs = Array.from({ length: width }, (_, i) =>
+			h("input", {
+				type: "text",
+				// The last column keeps any extra separators.
+				value: i === width - 1 ? cells.slice(i).join(separator) : cells[i] ?? "",
+				"aria-label": columns ? columns[i] : option.name,
+			}),
+		);
+		const row = h(
+			"tr",
+			{},
+			inputs.map((input) => h("td", {}, input)),
+			h("td", {}, h("button", { type: "button", onclick: () => row.remove() }, "Remove")),
+		);
+		row.values = () => inputs.map((input) => input.value);
+		tbody.append(row);
+	};
+	current.forEach((v) => addRow(v));
+
+	const table = h(
+		"div",
+		{},
+		h("table", {}, columns && h("thead", {}, h("tr", {}, columns.map((c) => h("th", {}, c)), h("th"))), tbody),
+		h("button", { type: "button", onclick: () => addRow() }, "Add"),
+	);
+
+	return {
+		element: table,
+		read() {
+			const values = Array.from(tbody.children)
+				.map((row) => row.values().join(separator ?? ""))
+				.filter((v) => v !== "");
+			if (JSON.stringify(values) === JSON.stringify(current)) {
+				return null;
+			}
+			return { id: option.id, stringList: { values } };
+		},
+	};
+}
+
+function optionControl(option, value, sensitiveHidden) {
+	if (option.string) {
+		return stringControl(option, option.string, value, sensitiveHidden);
+	}
+	if (option.stringList) {
+		return stringListControl(option, option.stringList, value);
+	}
+	if (option.int) {
+		return numberControl(option, option.int, value);
+	}
+	if (option.switch) {
+		return switchControl(option, option.switch, value);
+	}
+	return null;
+}
+
+function renderOption(option, value, controls, errors, sensitiveHidden) {
+	const control = optionControl(option, value, sensitiveHidden);
+	if (!control) {
+		console.warn("unknown option type:", option);
+		return null;
+	}
+
+	const error = h("p", { class: "error" });
+	controls.push(control);
+	errors.set(option.id, error);
+
+	const description = option.description && h("p", { class: "description muted" }, option.description);
+	if (option.switch) {
+		return h("div", { class: "option" }, h("label", { class: "switch" }, control.element, option.name), description, error);
+	}
+	return h("div", { class: "option" }, h("label", {}, option.name, control.element), description, error);
+}
+
+async function renderSettings(name) {
+	if (!session.get()) {
+		location.hash = "#/login";
+		return;
+	}
+
+	const path = `/services/${encodeURIComponent(name)}/cp`;
+	const { service, values, sensitiveHidden } = await api("GET", path);
+	const schema = service.optionsSchema || {};
+
+	const valueMap = new Map((values || []).map((v) => [v.id, v]));
+	const controls = [];
+	const errors = new Map();
+	const render = (o) => renderOption(o, valueMap.get(o.id), controls, errors, sensitiveHidden);
+
+	const sections = [];
+	if (schema.options?.length) {
+		sections.push(h("fieldset", {}, schema.options.map(render)));
+	}
+	for (const category of schema.categories || []) {
+		sections.push(
+			h(
+				"fieldset",
+				{},
+				h("legend", {}, category.title),
+				category.description && h("p", { class: "muted" }, category.description),
+				(category.options || []).map(render),
+			),
+		);
+	}
+
+	const status = h("p");
+	const submit = h("button", { type: "submit", class: "primary" }, "Save");
+	const form = h(
+		"form",
+		{
+			onsubmit: async (ev) => {
+				ev.preventDefault();
+				errors.forEach((el) => (el.textContent = ""));
+				status.textContent = "";
+				status.className = "";
+
+				// Only changed values are sent, which leaves sensitive values
+				// alone.
+				const changed = controls.map((c) => c.read()).filter(Boolean);
+				if (changed.length === 0) {
+					status.textContent = "Nothing to save.";
+					status.className = "muted";
+					return;
+				}
+
+				submit.disabled = true;
+				try {
+					const resp = await api("PATCH", path, { values: changed });
+					if (resp.success) {
+						await renderSettings(name);
+						return;
+					}
+					for (const err of resp.errors || []) {
+						const el = errors.get(err.optionId);
+						if (el) {
+							el.textContent = err.message;
+						} else {
+							status.textContent = err.message;
+						}
+					}
+					status.textContent ||= "Some values could not be saved.";
+					status.className = "error";
+				} catch (err) {
+					status.textContent = err.message;
+					status.className = "error";
+				} finally {
+					submit.disabled = false;
+				}
+			},
+		},
+		sections.length > 0 ? sections : h("p", { class: "muted" }, "This service has no settings."),
+		sections.length > 0 && h("div", { class: "actions" }, submit, status),
+	);
+
+	show(
+		h(
+			"div",
+			{ class: "card", style: serviceStyle(service) },
+			serviceTitle(service, "h1"),
+			h("a", { href: `#/services/${encodeURIComponent(service.name)}` }, "Manual"),
+		),
+		sensitiveHidden &&
+			h(
+				"p",
+				{ class: "muted" },
+				"Sensitive settings can only be viewed and changed after logging in using TOTP. ",
+				h("a", { href: "#/security" }, "Set up TOTP"),
+			),
+		form,
+	);
+}
+
+// renderSecurity shows the TOTP status and lets the user enable it, replace
+// their recovery codes or disable it.
+async function renderSecurity() {
+	if (!session.get()) {
+		location.hash = "#/login";
+		return;
+	}
+
+	const status = await api("GET", "/totp");
+	const message = h("p", { class: "error" });
+
+	// codeForm asks for a code and calls the run function of the action whose
+	// button was pressed with it.
+	const codeForm = (label, ...actions) => {
+		const code = h("input", { type: "text", autocomplete: "one-time-code", required: true });
+		return h(
+			"form",
+			{
+				onsubmit: async (ev) => {
+					ev.preventDefault();
+					message.textContent = "";
+					const action = actions.find((a) => a.button === ev.submitter) ?? actions[0];
+					action.button.disabled = true;
+					try {
+						await action.run(code.value.trim());
+					} catch (err) {
+						message.textContent = err.message;
+					} finally {
+						action.button.disabled = false;
+					}
+				},
+			},
+			h("label", {}, label, code),
+			h("div", { class: "actions" }, actions.map((a) => a.button), message),
+		);
+	};
+
+	const action = (text, run, primary = false) => ({
+		button: h("button", { type: "submit", class: primary ? "primary" : undefined }, text),
+		run,
+	});
+
+	const showRecoveryCodes = (codes) =>
+		show(
+			h("h1", {}, "Recovery codes"),
+			h(
+				"p",
+				{},
+				"Each code can be used once in place of an authenticator code. " +
+					"Keep them somewhere safe: they won't be shown again.",
+			),
+			h("pre", {}, codes.join("\n")),
+			h("a", { href: "#/security", onclick: () => route() }, "Done"),
+		);
+
+	if (status.enabled) {
+		show(
+			h("h1", {}, "Security"),
+			h("p", {}, "TOTP is enabled. Logging in requires a code from your authenticator app."),
+			h("p", { class: "muted" }, `${status.recoveryCodesLeft ?? 0} recovery codes left.`),
+			codeForm(
+				"Authenticator or recovery code",
+				action(
+					"Replace recovery codes",
+					async (code) => {
+						const resp = await api("POST", "/totp/recovery_codes", { code });
+						showRecoveryCodes(resp.recoveryCodes);
+					},
+					true,
+				),
+				action("Disable TOTP", async (code) => {
+					await api("POST", "/totp/disable", { code });
+					await renderSecurity();
+				}),
+			),
+		);
+		return;
+	}
+
+	const enroll = async () => {
+		const { secret, provisioningUri } = await api("POST", "/totp/enroll", {});
+		show(
+			h("h1", {}, "Set up TOTP"),
+			h(
+				"p",
+				{},
+				"Open ",
+				h("a", { href: provisioningUri }, "this link"),
+				" on the device with your authenticator app, or enter this secret manually:",
+			),
+			h("pre", {}, secret),
+			codeForm(
+				"Code from your authenticator app",
+				action(
+					"Enable TOTP",
+					async (code) => {
+						const resp = await api("POST", "/totp/confirm", { code });
+						showRecoveryCodes(resp.recoveryCodes);
+					},
+					true,
+				),
+			),
+		);
+	};
+
+	show(
+		h("h1", {}, "Security"),
+		h(
+			"p",
+			{},
+			"TOTP is disabled. Enabling it makes logging in also require a code from an authenticator app.",
+		),
+		h(
+			"div",
+			{ class: "actions" },
+			h(
+				"button",
+				{
+					class: "primary",
+					onclick: () => enroll().catch(showError),
+				},
+				"Set up TOTP",
+			),
+		),
+	);
+}
+
+async function route() {
+	const parts = location.hash
+		.replace(/^#\/?/, "")
+		.split("/")
+		.filter(Boolean)
+		.map(decodeURIComponent);
+
+	try {
+		switch (true) {
+			case parts.length === 0:
+				return await renderServices();
+			case parts.length === 1 && parts[0] === "login":
+				return renderLogin();
+			case parts.length === 1 && parts[0] === "security":
+				return await renderSecurity();
+			case parts.length === 2 && parts[0] === "services":
+				return await renderManual(parts[1]);
+			case parts.length === 3 && parts[0] === "services" && parts[2] === "settings":
+				return await renderSettings(parts[1]);
+			default:
+				show(h("p", { class: "error" }, "Page not found."));
+		}
+	} catch (err) {
+		if (err instanceof APIError && err.status === 401) {
+			location.hash = "#/login";
+			return;
+		}
+		showError(err);
+	}
+}
+
+window.addEventListener("hashchange", route);
+renderNav();
+route();
@@ -0,0 +1,37 @@
+// Package webui embeds the twid control panel, a single page application that
+// uses the API to log in, list services, show their manual pages and edit their
+// control panels.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// ContentSecurityPolicy is the Content-Security-Policy that the control panel
+// should be served with. It only allows the control panel's own files, plus
+// service icons from anywhere, and forbids framing it.
+const ContentSecurityPolicy = "default-src 'self'; img-src 'self' https:; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+// Handler returns a handler that serves the control panel. It expects the API
+// to be served at api relative to where it is mounted. It doesn't set any
+// security headers, so it should be wrapped in a middleware that sets them
+// with [ContentSecurityPolicy].
+func Handler() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the path is embedded, so this can't happen
+	}
+
+	files := http.FileServerFS(root)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The files aren't versioned, so make sure that a new binary is
+		// picked up.
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
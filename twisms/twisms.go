@@ -120,6 +120,35 @@ type MessageService interface {
 	MessageSender
 }
 
+// HealthReporter describes a service that can report whether it is currently
+// able to deliver messages. It is optional and is used to route messages away
+// from services that are down.
+type HealthReporter interface {
+	// Healthy returns true if the service is currently able to deliver
+	// messages.
+	Healthy() bool
+}
+
+// NumberOwner describes a service that only sends messages from a known set
+// of phone numbers. It is optional and is used to route messages to the
+// services that own their sending number.
+type NumberOwner interface {
+	// PhoneNumbers returns the phone numbers that the service can send
+	// messages from.
+	PhoneNumbers() []string
+}
+
+// MessageBodyType returns the type of the given message body. It returns
+// "text" for text messages and an empty string if the body is empty.
+func MessageBodyType(body *twismsproto.MessageBody) string {
+	switch {
+	case body.GetText() != nil:
+		return "text"
+	default:
+		return ""
+	}
+}
+
 type combinedMessageService struct {
 	MessageSubscriber
 	MessageSender
@@ -26,12 +26,12 @@ func init() {
 	twid.RegisterTwismsModule(twid.TwismsModule{
 		Name: "wsbridge_client",
 		Desc: "Proxy message sends and receives over a Websocket client",
-		New: func(raw json.RawMessage, logger *slog.Logger) (twisms.MessageService, error) {
+		New: func(raw json.RawMessage, mctx twid.ModuleContext) (twisms.MessageService, error) {
 			var cfg ClientServiceConfig
 			if err := json.Unmarshal(raw, &cfg); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 			}
-			return NewClientService(cfg, logger), nil
+			return NewClientService(cfg, mctx.Logger), nil
 		},
 	})
 }
@@ -62,6 +62,8 @@ type ClientService struct {
 var (
 	_ twisms.MessageSender     = (*ClientService)(nil)
 	_ twisms.MessageSubscriber = (*ClientService)(nil)
+	_ twisms.HealthReporter    = (*ClientService)(nil)
+	_ twisms.NumberOwner       = (*ClientService)(nil)
 )
 
 // NewClientService creates a new Service using the given Websocket address.
@@ -264,6 +266,17 @@ func (s *ClientService) SendingNumber() (string, float64) {
 	return s.cfg.PhoneNumbers[0], 0.0
 }
 
+// PhoneNumbers implements [twisms.NumberOwner].
+func (s *ClientService) PhoneNumbers() []string {
+	return s.cfg.PhoneNumbers
+}
+
+// Healthy implements [twisms.HealthReporter]. The service is healthy if it is
+// currently connected to the wsbridge server.
+func (s *ClientService) Healthy() bool {
+	return s.conn.Load() != nil
+}
+
 // SubscribeMessages implements [twisms.MessageSubscriber].
 func (s *ClientService) SubscribeMessages(ch chan<- *twismsproto.Message, filters *twismsproto.MessageFilters) {
 	s.subs.Subscribe(ch, func(msg *twismsproto.Message) bool {
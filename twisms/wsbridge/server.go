@@ -31,12 +31,12 @@ func init() {
 	twid.RegisterTwismsModule(twid.TwismsModule{
 		Name: "wsbridge_server",
 		Desc: "Proxy message sends and receives over a Websocket server",
-		New: func(raw json.RawMessage, logger *slog.Logger) (twisms.MessageService, error) {
+		New: func(raw json.RawMessage, mctx twid.ModuleContext) (twisms.MessageService, error) {
 			var cfg ServerServiceConfig
 			if err := json.Unmarshal(raw, &cfg); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 			}
-			return NewServerService(cfg, logger), nil
+			return NewServerService(cfg, mctx.Logger), nil
 		},
 	})
 }
@@ -79,6 +79,8 @@ var (
 	_ http.Handler             = (*ServerService)(nil)
 	_ twisms.MessageSender     = (*ServerService)(nil)
 	_ twisms.MessageSubscriber = (*ServerService)(nil)
+	_ twisms.HealthReporter    = (*ServerService)(nil)
+	_ twisms.NumberOwner       = (*ServerService)(nil)
 )
 
 // NewServerService creates a new Service using the given Websocket address.
@@ -163,6 +165,18 @@ func (s *ServerService) SendingNumber() (string, float64) {
 	return s.cfg.PhoneNumbers[0], 0.0
 }
 
+// PhoneNumbers implements [twisms.NumberOwner].
+func (s *ServerService) PhoneNumbers() []string {
+	return s.cfg.PhoneNumbers
+}
+
+// Healthy implements [twisms.HealthReporter]. The service is healthy once it
+// has started accepting connections.
+func (s *ServerService) Healthy() bool {
+	_, ok := s.service.Value()
+	return ok
+}
+
 type serverService struct {
 	*ServerService
 	queue *catchupstorage.MessageQueue